@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"qgencodex/internal/align"
+	"qgencodex/internal/audio"
+	"qgencodex/internal/config"
+	"qgencodex/internal/recognize"
+	"qgencodex/internal/utils"
+)
+
+// newAligner returns the speech backend selected by audio.aligner, split into
+// parallel chunks for long recordings and wrapped in the alignment cache
+// unless audio.align_cache is off.
+func newAligner(cfg config.AudioConfig, logger *utils.Logger) align.Backend {
+	backend := newBackend(cfg, logger)
+	if cfg.ChunkSec > 0 {
+		chunked := align.NewChunkedAligner(backend,
+			time.Duration(cfg.ChunkSec)*time.Second,
+			time.Duration(cfg.ChunkOverlapSec*float64(time.Second)),
+			cfg.TranscribeWorkers)
+		chunked.Silences = func(ctx context.Context, audioPath string) ([]audio.Silence, error) {
+			return detectPauses(ctx, audioPath, cfg)
+		}
+		backend = chunked
+	}
+	if !cfg.AlignCache {
+		return backend
+	}
+	dir, err := alignCacheDir(cfg)
+	if err != nil {
+		return backend
+	}
+	return align.NewCachedAligner(backend, dir, alignerFingerprint(cfg))
+}
+
+func newBackend(cfg config.AudioConfig, logger *utils.Logger) align.Backend {
+	opts := whisperOptions(cfg)
+	switch strings.ToLower(cfg.Aligner) {
+	case "whisper-cpp":
+		aligner := align.NewWhisperCppAligner(cfg.WhisperCppCmd, cfg.WhisperCppModel)
+		aligner.Options = opts
+		aligner.Logf = logger.Infof
+		return aligner
+	case "http":
+		timeout := time.Duration(cfg.TranscribeTimeoutSec) * time.Second
+		aligner := align.NewHTTPAligner(cfg.TranscribeURL, cfg.TranscribeModel, cfg.TranscribeAPIKey, timeout, cfg.TranscribeRetries)
+		aligner.Options = opts
+		return aligner
+	default:
+		aligner := align.NewWhisperAligner(cfg.WhisperCmd)
+		aligner.Options = opts
+		aligner.Logf = logger.Infof
+		aligner.Warnf = logger.Warnf
+		return aligner
+	}
+}
+
+func newTranscriber(cfg config.AudioConfig, logger *utils.Logger) recognize.Transcriber {
+	switch strings.ToLower(cfg.Aligner) {
+	case "whisper-cpp", "http":
+		return recognize.NewBackendRecognizer(newAligner(cfg, logger))
+	default:
+		recognizer := recognize.NewWhisperRecognizer(cfg.WhisperCmd)
+		recognizer.Options = whisperOptions(cfg)
+		recognizer.Logf = logger.Infof
+		recognizer.Warnf = logger.Warnf
+		return recognizer
+	}
+}
+
+func whisperOptions(cfg config.AudioConfig) align.WhisperOptions {
+	return align.WhisperOptions{
+		Model:     cfg.Whisper.Model,
+		Device:    cfg.Whisper.Device,
+		Threads:   cfg.Whisper.Threads,
+		BeamSize:  cfg.Whisper.BeamSize,
+		Prompt:    cfg.Whisper.Prompt,
+		VADFilter: cfg.Whisper.VADFilter,
+		ExtraArgs: cfg.Whisper.ExtraArgs,
+	}
+}
+
+func alignerName(cfg config.AudioConfig) string {
+	switch strings.ToLower(cfg.Aligner) {
+	case "whisper-cpp":
+		return "whisper.cpp"
+	case "http":
+		return "Transcription server"
+	default:
+		return "Whisper"
+	}
+}
+
+func alignCacheDir(cfg config.AudioConfig) (string, error) {
+	if cfg.AlignCacheDir != "" {
+		return cfg.AlignCacheDir, nil
+	}
+	return config.DefaultAlignCacheDir()
+}
+
+// alignerFingerprint captures the settings that change transcription output,
+// so switching backend or model never reuses stale cache entries.
+func alignerFingerprint(cfg config.AudioConfig) string {
+	var parts []string
+	switch strings.ToLower(cfg.Aligner) {
+	case "whisper-cpp":
+		parts = []string{"whisper-cpp", cfg.WhisperCppCmd, cfg.WhisperCppModel}
+	case "http":
+		parts = []string{"http", cfg.TranscribeURL, cfg.TranscribeModel}
+	default:
+		parts = []string{"whisper", cfg.WhisperCmd}
+	}
+	parts = append(parts, whisperOptions(cfg).Fingerprint())
+	if cfg.ChunkSec > 0 {
+		parts = append(parts, fmt.Sprintf("chunk=%d/%g", cfg.ChunkSec, cfg.ChunkOverlapSec))
+	}
+	return strings.Join(parts, "|")
+}
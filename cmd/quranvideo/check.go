@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"qgencodex/internal/config"
+	"qgencodex/internal/quran"
+	"qgencodex/internal/recognize"
+	"qgencodex/internal/render"
+	"qgencodex/internal/tasmee"
+	"qgencodex/internal/utils"
+)
+
+// mistakeColors highlights each kind of mistake in the annotated video.
+var mistakeColors = map[tasmee.Kind]string{
+	tasmee.Skipped:     "#FFA500",
+	tasmee.Inserted:    "#C080FF",
+	tasmee.Substituted: "#FF4040",
+	tasmee.Repeated:    "#FFE040",
+	tasmee.OutOfOrder:  "#40A0FF",
+}
+
+func checkCmd(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	audioPath := fs.String("audio", "", "Recitation audio file")
+	inputPath := fs.String("input", "", "Recitation audio or video file")
+	expectedSurah := fs.Int("expected-surah", 0, "Optional expected surah number (1-114)")
+	surah := fs.Int("surah", 0, "Surah being recited (1-114); identified from the audio when omitted")
+	startAyah := fs.Int("start", 0, "First ayah being recited")
+	endAyah := fs.Int("end", 0, "Last ayah being recited")
+	configPath := fs.String("config", "", "Config file path")
+	jsonOut := fs.Bool("json", false, "Print the report as JSON")
+	video := fs.String("video", "", "Also render an annotated word-by-word video with mistakes highlighted")
+	_ = fs.Parse(args)
+	if *audioPath == "" && *inputPath == "" {
+		exitWithError(fmt.Errorf("audio or input path is required"))
+	}
+	cfg, created, err := loadConfig(*configPath)
+	if err != nil {
+		exitWithError(err)
+	}
+	logger := utils.NewLogger(cfg.Logging.Level)
+	if *jsonOut {
+		// Keep stdout for the JSON document.
+		logger.SetOutput(os.Stderr)
+	}
+	if created {
+		logger.Infof("Created default config at %s", resolveConfigPath(*configPath))
+	}
+	if *inputPath != "" {
+		source, err := prepareRecitationInput(ctx, *inputPath, cfg.Output.TempDir, cfg.Audio.BitrateKbps, logger)
+		if err != nil {
+			exitWithError(err)
+		}
+		*audioPath = source.AudioPath
+	}
+	aligner := newAligner(cfg.Audio, logger)
+	if !aligner.Available() {
+		exitWithError(fmt.Errorf("%s not available", alignerName(cfg.Audio)))
+	}
+	words, err := aligner.TranscribeWords(ctx, *audioPath, cfg.Audio.Language)
+	if err != nil {
+		exitWithError(err)
+	}
+	result := recognize.Result{Surah: *surah, StartAyah: *startAyah, EndAyah: *endAyah}
+	if *surah <= 0 || *startAyah <= 0 || *endAyah <= 0 {
+		matcher := newMatcher(cfg.QuranAPI, *expectedSurah, logger)
+		if result, err = matcher.Identify(ctx, transcriptText(words)); err != nil {
+			exitWithError(fmt.Errorf("identify recitation: %w; pass --surah, --start and --end", err))
+		}
+		logger.Infof("Detected recitation: Surah %d, Ayahs %d-%d", result.Surah, result.StartAyah, result.EndAyah)
+	}
+	client := quran.NewClient(cfg.QuranAPI.BaseURL, time.Duration(cfg.QuranAPI.TimeoutSec)*time.Second)
+	verses, err := client.FetchVerses(ctx, result.Surah, result.StartAyah, result.EndAyah, cfg.QuranAPI.Edition, "")
+	if err != nil {
+		exitWithError(err)
+	}
+	report := tasmee.Check(verses, words)
+	if *jsonOut {
+		err = writeCheckJSON(os.Stdout, result, report)
+	} else {
+		printCheckReport(result, report)
+	}
+	if err != nil {
+		exitWithError(err)
+	}
+	if *video != "" {
+		if err := renderCheckVideo(ctx, cfg, *audioPath, *video, verses, report, logger); err != nil {
+			exitWithError(err)
+		}
+	}
+}
+
+// checkOutput is the `check --json` document.
+type checkOutput struct {
+	Surah     int           `json:"surah"`
+	StartAyah int           `json:"start_ayah"`
+	EndAyah   int           `json:"end_ayah"`
+	Words     int           `json:"words"`
+	Correct   int           `json:"correct"`
+	Accuracy  float64       `json:"accuracy"`
+	Issues    []issueOutput `json:"issues"`
+}
+
+type issueOutput struct {
+	Kind     string  `json:"kind"`
+	Surah    int     `json:"surah"`
+	Ayah     int     `json:"ayah"`
+	Word     int     `json:"word"`
+	Expected string  `json:"expected,omitempty"`
+	Recited  string  `json:"recited,omitempty"`
+	Start    float64 `json:"start"`
+	End      float64 `json:"end"`
+}
+
+func writeCheckJSON(w io.Writer, result recognize.Result, report tasmee.Report) error {
+	out := checkOutput{
+		Surah:     result.Surah,
+		StartAyah: result.StartAyah,
+		EndAyah:   result.EndAyah,
+		Words:     len(report.Words),
+		Correct:   report.CorrectWords(),
+		Accuracy:  round3(report.Accuracy()),
+		Issues:    []issueOutput{},
+	}
+	for _, issue := range report.Issues {
+		out.Issues = append(out.Issues, issueOutput{
+			Kind:     string(issue.Kind),
+			Surah:    issue.Surah,
+			Ayah:     issue.Ayah,
+			Word:     issue.Word,
+			Expected: issue.Expected,
+			Recited:  issue.Recited,
+			Start:    seconds(issue.Start),
+			End:      seconds(issue.End),
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+func printCheckReport(result recognize.Result, report tasmee.Report) {
+	fmt.Printf("Surah %d, Ayahs %d-%d: %d of %d words correct (%.0f%%)\n",
+		result.Surah, result.StartAyah, result.EndAyah, report.CorrectWords(), len(report.Words), report.Accuracy()*100)
+	for _, issue := range report.Issues {
+		fmt.Println(formatIssue(issue))
+	}
+}
+
+func formatIssue(issue tasmee.Issue) string {
+	text := fmt.Sprintf("%s  %-12s %d:%d", formatClock(issue.Start), issue.Kind, issue.Ayah, issue.Word)
+	switch issue.Kind {
+	case tasmee.Substituted:
+		text += fmt.Sprintf("  expected %q, recited %q", issue.Expected, issue.Recited)
+	case tasmee.Inserted:
+		text += fmt.Sprintf("  %q", issue.Recited)
+	default:
+		text += fmt.Sprintf("  %q", issue.Expected)
+	}
+	return text
+}
+
+// renderCheckVideo renders the recitation word by word with the reference
+// text, coloring mistakes and showing inserted and repeated words in place.
+func renderCheckVideo(ctx context.Context, cfg *config.Config, audioPath, output string, verses []quran.Verse, report tasmee.Report, logger *utils.Logger) error {
+	timings := checkTimings(verses, report)
+	if len(timings) == 0 {
+		return fmt.Errorf("nothing recited to render")
+	}
+	tempDir := filepath.Join(cfg.Output.TempDir, "check")
+	input, err := checkGlyphs(render.RenderInput{
+		Timings:     timings,
+		AudioPath:   audioPath,
+		OutputPath:  output,
+		TempDir:     tempDir,
+		Mode:        config.ModeChunk + ":1",
+		VideoConfig: cfg.Video,
+	}, logger)
+	if err != nil {
+		return err
+	}
+	logger.Infof("Rendering annotated video")
+	if err := render.Render(ctx, input); err != nil {
+		return err
+	}
+	logger.Infof("Video generated: %s", output)
+	return nil
+}
+
+func checkTimings(verses []quran.Verse, report tasmee.Report) []render.Timing {
+	byAyah := make(map[int][]render.WordTiming)
+	for _, w := range report.Words {
+		if w.End <= w.Start {
+			continue
+		}
+		byAyah[w.Ayah] = append(byAyah[w.Ayah], render.WordTiming{Word: w.Text, Start: w.Start, End: w.End, Color: mistakeColors[w.Kind]})
+	}
+	for _, issue := range report.Issues {
+		if issue.Kind != tasmee.Inserted && issue.Kind != tasmee.Repeated {
+			continue
+		}
+		byAyah[issue.Ayah] = append(byAyah[issue.Ayah], render.WordTiming{Word: issue.Recited, Start: issue.Start, End: issue.End, Color: mistakeColors[issue.Kind]})
+	}
+	var timings []render.Timing
+	for _, v := range verses {
+		words := byAyah[v.NumberInSurah]
+		if len(words) == 0 {
+			continue
+		}
+		sort.SliceStable(words, func(a, b int) bool { return words[a].Start < words[b].Start })
+		t := render.Timing{Verse: v, Start: words[0].Start, WordTimings: words}
+		for _, w := range words {
+			if w.End > t.End {
+				t.End = w.End
+			}
+		}
+		timings = append(timings, t)
+	}
+	sort.SliceStable(timings, func(a, b int) bool { return timings[a].End < timings[b].End })
+	return timings
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"qgencodex/internal/render"
+	"qgencodex/internal/utils"
+)
+
+// checkGlyphs applies video.glyphs before rendering: substitutions, a
+// fallback font when video.font lacks characters of the text, and a warning
+// or error for characters still missing.
+func checkGlyphs(input render.RenderInput, logger *utils.Logger) (render.RenderInput, error) {
+	checked, report, err := render.PrepareGlyphs(input)
+	if err != nil {
+		return input, err
+	}
+	for _, name := range report.Unresolved {
+		logger.Warnf("Glyph fallback %q not found", name)
+	}
+	if font := input.VideoConfig.Font.File; report.Font != "" && report.Font != font {
+		logger.Infof("Font %s lacks characters of the text; using fallback %s", filepath.Base(font), report.Font)
+	}
+	policy := strings.ToLower(input.VideoConfig.Glyphs.OnMissing)
+	if policy == "ignore" {
+		return checked, nil
+	}
+	var problems []string
+	if len(report.Missing) > 0 {
+		problems = append(problems, fmt.Sprintf("%s has no glyph for %s", filepath.Base(report.Font), render.FormatRunes(report.Missing)))
+	}
+	if len(report.MissingTranslation) > 0 {
+		problems = append(problems, fmt.Sprintf("translation font %s has no glyph for %s", filepath.Base(report.TranslationFont), render.FormatRunes(report.MissingTranslation)))
+	}
+	if len(problems) == 0 {
+		return checked, nil
+	}
+	if policy == "fail" {
+		return input, fmt.Errorf("missing glyphs: %s; set video.glyphs.fallbacks or video.glyphs.substitute", strings.Join(problems, "; "))
+	}
+	logger.Warnf("Missing glyphs, shown as boxes: %s", strings.Join(problems, "; "))
+	return checked, nil
+}
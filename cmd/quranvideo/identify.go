@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"qgencodex/internal/align"
+	"qgencodex/internal/config"
+	"qgencodex/internal/recognize"
+	"qgencodex/internal/utils"
+)
+
+// identifyOutput is the `identify --json` document. Reliable mirrors the
+// thresholds of the plain output, so automation can route the rest to a human.
+type identifyOutput struct {
+	Transcript string            `json:"transcript"`
+	Reliable   bool              `json:"reliable"`
+	Candidates []candidateOutput `json:"candidates"`
+	Ayahs      []ayahTimeOutput  `json:"ayahs,omitempty"`
+}
+
+type candidateOutput struct {
+	Surah      int     `json:"surah"`
+	StartAyah  int     `json:"start_ayah"`
+	EndAyah    int     `json:"end_ayah"`
+	Matches    int     `json:"matches"`
+	Length     int     `json:"length"`
+	Score      int     `json:"score"`
+	Coverage   float64 `json:"coverage"`
+	Confidence float64 `json:"confidence"`
+	Reliable   bool    `json:"reliable"`
+}
+
+type ayahTimeOutput struct {
+	Surah int     `json:"surah"`
+	Ayah  int     `json:"ayah"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// writeIdentifyJSON transcribes with word timestamps, ranks candidates and
+// adds per-ayah times for the best one when it is reliable.
+func writeIdentifyJSON(ctx context.Context, w io.Writer, cfg *config.Config, matcher *recognize.Matcher, audioPath string, top int, logger *utils.Logger) error {
+	aligner := newAligner(cfg.Audio, logger)
+	if !aligner.Available() {
+		return fmt.Errorf("%s not available", alignerName(cfg.Audio))
+	}
+	words, err := aligner.TranscribeWords(ctx, audioPath, cfg.Audio.Language)
+	if err != nil {
+		return err
+	}
+	out := identifyOutput{Transcript: transcriptText(words), Candidates: []candidateOutput{}}
+	if top < 1 {
+		top = 1
+	}
+	candidates, err := matcher.Candidates(ctx, out.Transcript, top)
+	if err != nil {
+		return err
+	}
+	for _, c := range candidates {
+		out.Candidates = append(out.Candidates, candidateOutput{
+			Surah:      c.Surah,
+			StartAyah:  c.StartAyah,
+			EndAyah:    c.EndAyah,
+			Matches:    c.Matches,
+			Length:     c.Length,
+			Score:      c.Score,
+			Coverage:   round3(c.Coverage),
+			Confidence: round3(c.Confidence),
+			Reliable:   c.Reliable,
+		})
+	}
+	if len(candidates) > 0 && candidates[0].Reliable {
+		out.Reliable = true
+		times, err := matcher.AyahTimes(ctx, candidates[0].Result, words)
+		if err != nil {
+			logger.Warnf("Ayah times unavailable: %v", err)
+		}
+		for _, t := range times {
+			out.Ayahs = append(out.Ayahs, ayahTimeOutput{Surah: t.Surah, Ayah: t.Ayah, Start: seconds(t.Start), End: seconds(t.End)})
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// transcriptText joins timed words into the plain transcript the matcher reads.
+func transcriptText(words []align.WordTiming) string {
+	parts := make([]string, 0, len(words))
+	for _, wt := range words {
+		if word := strings.TrimSpace(wt.Word); word != "" {
+			parts = append(parts, word)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatCandidate(c recognize.Candidate) string {
+	text := fmt.Sprintf("Surah %d, Ayahs %d-%d (confidence %.2f, %d matched, coverage %.2f)", c.Surah, c.StartAyah, c.EndAyah, c.Confidence, c.Matches, c.Coverage)
+	if !c.Reliable {
+		text += " [unreliable]"
+	}
+	return text
+}
+
+func round3(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
+
+func seconds(d time.Duration) float64 {
+	return round3(d.Seconds())
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"qgencodex/internal/config"
+	"qgencodex/internal/recognize"
+	"qgencodex/internal/utils"
+)
+
+func indexCmd(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("index", flag.ExitOnError)
+	configPath := fs.String("config", "", "Config file path")
+	output := fs.String("output", "", "Index path (default quran_api.index_path or ~/.quranvideo/index/<edition>.gob)")
+	_ = fs.Parse(args)
+	if fs.NArg() == 0 {
+		fmt.Println("Usage: quranvideo index build [--output path]")
+		return
+	}
+	switch fs.Arg(0) {
+	case "build":
+		cfg, _, err := loadConfig(*configPath)
+		if err != nil {
+			exitWithError(err)
+		}
+		logger := utils.NewLogger(cfg.Logging.Level)
+		path := *output
+		if path == "" {
+			if path, err = indexPath(cfg.QuranAPI); err != nil {
+				exitWithError(err)
+			}
+		}
+		logger.Infof("Fetching %s for the recognition index", cfg.QuranAPI.Edition)
+		ix, err := recognize.BuildIndex(ctx, apiCorpus(cfg.QuranAPI), cfg.QuranAPI.Edition, recognize.DefaultGramSize)
+		if err != nil {
+			exitWithError(err)
+		}
+		if err := ix.Save(path); err != nil {
+			exitWithError(err)
+		}
+		fmt.Printf("Indexed %d n-grams to %s\n", len(ix.Grams), path)
+	default:
+		fmt.Println("Unknown index command")
+	}
+}
+
+func indexPath(cfg config.QuranAPIConfig) (string, error) {
+	if cfg.IndexPath != "" {
+		return cfg.IndexPath, nil
+	}
+	return config.DefaultIndexPath(cfg.Edition)
+}
+
+func apiCorpus(cfg config.QuranAPIConfig) *recognize.APICorpus {
+	return &recognize.APICorpus{BaseURL: cfg.BaseURL, Edition: cfg.Edition, Timeout: time.Duration(cfg.TimeoutSec) * time.Second}
+}
+
+// newMatcher uses the offline index when one was built for the configured
+// edition and falls back to fetching surahs from the API.
+func newMatcher(cfg config.QuranAPIConfig, expectedSurah int, logger *utils.Logger) *recognize.Matcher {
+	matcher := &recognize.Matcher{Corpus: apiCorpus(cfg), ExpectedSurah: expectedSurah}
+	path, err := indexPath(cfg)
+	if err != nil {
+		return matcher
+	}
+	ix, err := recognize.LoadIndex(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		logger.Debugf("No recognition index at %s; run 'quranvideo index build' for offline identification", path)
+	case err != nil:
+		logger.Warnf("Ignoring recognition index: %v", err)
+	case ix.Edition != cfg.Edition:
+		logger.Warnf("Ignoring recognition index %s: built for %s, config uses %s", path, ix.Edition, cfg.Edition)
+	default:
+		matcher.Index = ix
+	}
+	return matcher
+}
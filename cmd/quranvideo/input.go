@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"qgencodex/internal/audio"
+	"qgencodex/internal/ffmpeg"
+	"qgencodex/internal/utils"
+)
+
+// recitationSource is a recitation input resolved to an audio file, plus the
+// originating video when the input had a video track.
+type recitationSource struct {
+	AudioPath string
+	VideoPath string
+	Video     ffmpeg.Stream
+}
+
+func (s recitationSource) hasVideo() bool {
+	return s.VideoPath != ""
+}
+
+// prepareRecitationInput probes inputPath and extracts its audio track when it is a video.
+func prepareRecitationInput(ctx context.Context, inputPath, tempDir string, bitrate int, logger *utils.Logger) (recitationSource, error) {
+	if !utils.FileExists(inputPath) {
+		return recitationSource{}, fmt.Errorf("input file not found: %s", inputPath)
+	}
+	info, err := ffmpeg.ProbeStreams(ctx, inputPath)
+	if err != nil {
+		return recitationSource{}, err
+	}
+	audioStream, ok := info.Audio()
+	if !ok {
+		return recitationSource{}, fmt.Errorf("input has no audio stream: %s", inputPath)
+	}
+	video, ok := info.Video()
+	if !ok {
+		return recitationSource{AudioPath: inputPath}, nil
+	}
+	w, h := video.DisplaySize()
+	logger.Infof("Input video: %dx%d (%s, rotation %d), audio stream #%d (%s)", w, h, video.CodecName, video.Rotation, audioStream.Index, audioStream.CodecName)
+	if err := utils.EnsureDir(tempDir); err != nil {
+		return recitationSource{}, err
+	}
+	audioPath := filepath.Join(tempDir, "input_audio.mp3")
+	logger.Infof("Extracting audio track from input video")
+	if err := audio.ExtractAudio(ctx, inputPath, audioPath, audioStream.Index, bitrate); err != nil {
+		return recitationSource{}, err
+	}
+	return recitationSource{AudioPath: audioPath, VideoPath: inputPath, Video: video}, nil
+}
+
+type cropRect struct {
+	W, H, X, Y int
+}
+
+// parseCrop parses a crop spec in ffmpeg's w:h:x:y form.
+func parseCrop(spec string) (cropRect, error) {
+	parts := strings.Split(strings.TrimSpace(spec), ":")
+	if len(parts) != 4 {
+		return cropRect{}, fmt.Errorf("invalid crop %q: expected w:h:x:y", spec)
+	}
+	values := make([]int, 4)
+	for i, p := range parts {
+		v, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil || v < 0 {
+			return cropRect{}, fmt.Errorf("invalid crop %q: expected non-negative integers", spec)
+		}
+		values[i] = v
+	}
+	c := cropRect{W: values[0], H: values[1], X: values[2], Y: values[3]}
+	if c.W == 0 || c.H == 0 {
+		return cropRect{}, fmt.Errorf("invalid crop %q: width and height must be positive", spec)
+	}
+	return c, nil
+}
+
+// fits reports whether the crop lies inside a frame of the given display size.
+func (c cropRect) fits(width, height int) bool {
+	return c.X+c.W <= width && c.Y+c.H <= height
+}
+
+func (c cropRect) String() string {
+	return fmt.Sprintf("%d:%d:%d:%d", c.W, c.H, c.X, c.Y)
+}
+
+// resolveVideoCrop validates a crop spec against the source video. Crops are
+// given in display orientation; ffmpeg auto-rotates frames before filtering.
+func resolveVideoCrop(spec string, video ffmpeg.Stream) (string, error) {
+	if strings.TrimSpace(spec) == "" {
+		return "", nil
+	}
+	crop, err := parseCrop(spec)
+	if err != nil {
+		return "", err
+	}
+	w, h := video.DisplaySize()
+	if w > 0 && h > 0 && !crop.fits(w, h) {
+		return "", fmt.Errorf("crop %s exceeds input video size %dx%d", crop, w, h)
+	}
+	return crop.String(), nil
+}
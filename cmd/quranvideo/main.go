@@ -7,8 +7,10 @@ import (
 	"math"
 	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 	"unicode"
 
@@ -20,6 +22,7 @@ import (
 	"qgencodex/internal/caption"
 	"qgencodex/internal/config"
 	"qgencodex/internal/ffmpeg"
+	"qgencodex/internal/importer"
 	"qgencodex/internal/quran"
 	"qgencodex/internal/recognize"
 	"qgencodex/internal/render"
@@ -33,18 +36,27 @@ func main() {
 		usage()
 		return
 	}
+	// Ctrl+C cancels in-flight transcription and ffmpeg runs.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 	cmd := os.Args[1]
 	switch cmd {
 	case "generate":
-		generateCmd(os.Args[2:])
+		generateCmd(ctx, os.Args[2:])
 	case "generate-audio":
-		generateAudioCmd(os.Args[2:])
+		generateAudioCmd(ctx, os.Args[2:])
 	case "identify":
-		identifyCmd(os.Args[2:])
+		identifyCmd(ctx, os.Args[2:])
+	case "check":
+		checkCmd(ctx, os.Args[2:])
 	case "batch":
-		batchCmd(os.Args[2:])
+		batchCmd(ctx, os.Args[2:])
 	case "config":
 		configCmd(os.Args[2:])
+	case "cache":
+		cacheCmd(os.Args[2:])
+	case "index":
+		indexCmd(ctx, os.Args[2:])
 	case "version":
 		fmt.Println("quranvideo v0.1.0")
 	default:
@@ -58,31 +70,57 @@ func usage() {
 Usage:
   quranvideo generate [options]
   quranvideo generate-audio --audio recitation.mp3
+  quranvideo generate-audio --input recitation.mp4 --video-layout pip
   quranvideo identify --audio recitation.mp3
+  quranvideo check --audio recitation.mp3
   quranvideo batch --file batch.yaml
   quranvideo config init
+  quranvideo cache clear
+  quranvideo index build
   quranvideo version
 
 Run 'quranvideo generate -h' for generate options.`)
 }
 
-func generateAudioCmd(args []string) {
+func generateAudioCmd(ctx context.Context, args []string) {
 	fs := flag.NewFlagSet("generate-audio", flag.ExitOnError)
 	audioPath := fs.String("audio", "", "Recitation audio file")
+	inputPath := fs.String("input", "", "Recitation audio or video file")
 	expectedSurah := fs.Int("expected-surah", 0, "Optional expected surah number (1-114)")
 	surah := fs.Int("surah", 0, "Optional surah number (1-114)")
 	startAyah := fs.Int("start", 0, "Optional start ayah")
 	endAyah := fs.Int("end", 0, "Optional end ayah")
-	mode := fs.String("mode", "sequential", "Display mode: sequential|repeat|repeat-2x2|word-by-word")
+	mode := fs.String("mode", "sequential", "Display mode: sequential|repeat|repeat-2x2|word-by-word|2x2|chunk:N|chunk:auto|phrase|line-by-line|karaoke|progressive|teleprompter")
 	output := fs.String("output", "", "Output video path")
 	configPath := fs.String("config", "", "Config file path")
 	translation := fs.Bool("translation", true, "Include translation overlay")
 	backgroundPath := fs.String("background", "", "Custom background video path")
 	noBackground := fs.Bool("no-background", false, "Disable background video (solid color)")
+	videoLayout := fs.String("video-layout", "none", "Reuse the input video: none|background|pip")
+	videoCrop := fs.String("video-crop", "", "Crop the input video as w:h:x:y (display orientation)")
+	pipPosition := fs.String("pip-position", "top-right", "Inset position: top-left|top-right|bottom-left|bottom-right")
+	pipScale := fs.Float64("pip-scale", 0.35, "Inset width as a fraction of the video width")
+	noCache := fs.Bool("no-cache", false, "Ignore cached alignments and re-run transcription")
+	timingsPath := fs.String("timings", "", "Render from a timings file (JSON, SRT, LRC, Audacity labels, Whisper JSON) instead of aligning")
+	timingsFormat := fs.String("timings-format", "auto", "Timings file format: auto|json|srt|lrc|audacity|whisper")
+	exportTimings := fs.String("export-timings", "", "Write the final timings to a JSON file")
+	passagesFlag := fs.Bool("passages", false, "Detect several passages (surah changes) in one recording")
+	passageGap := fs.Float64("passage-gap", 2, "Minimum pause in seconds between passage segments")
 	_ = fs.Parse(args)
 
-	if *audioPath == "" {
-		exitWithError(fmt.Errorf("audio path is required"))
+	if *audioPath == "" && *inputPath == "" {
+		exitWithError(fmt.Errorf("audio or input path is required"))
+	}
+	layout := strings.ToLower(strings.TrimSpace(*videoLayout))
+	switch layout {
+	case "", "none", "background", "pip":
+	default:
+		exitWithError(fmt.Errorf("unsupported video layout: %s", *videoLayout))
+	}
+	switch strings.ToLower(strings.TrimSpace(*pipPosition)) {
+	case "top-left", "top-right", "bottom-left", "bottom-right":
+	default:
+		exitWithError(fmt.Errorf("unsupported pip position: %s", *pipPosition))
 	}
 
 	cfg, created, err := loadConfig(*configPath)
@@ -94,21 +132,58 @@ func generateAudioCmd(args []string) {
 		logger.Infof("Created default config at %s", resolveConfigPath(*configPath))
 	}
 
-	ctx := context.Background()
-	var result recognize.Result
+	source := recitationSource{AudioPath: *audioPath}
+	if *inputPath != "" {
+		source, err = prepareRecitationInput(ctx, *inputPath, cfg.Output.TempDir, cfg.Audio.BitrateKbps, logger)
+		if err != nil {
+			exitWithError(err)
+		}
+	}
+	crop := ""
+	if layout == "background" || layout == "pip" {
+		if !source.hasVideo() {
+			logger.Warnf("Video layout %q requires a video --input; ignoring", layout)
+			layout = "none"
+		} else if crop, err = resolveVideoCrop(*videoCrop, source.Video); err != nil {
+			exitWithError(err)
+		}
+	}
+	*audioPath = source.AudioPath
+
+	var (
+		result   recognize.Result
+		passages []recognize.Passage
+	)
 	if *surah > 0 && *startAyah > 0 && *endAyah > 0 {
 		result = recognize.Result{Surah: *surah, StartAyah: *startAyah, EndAyah: *endAyah}
 		logger.Infof("Using provided recitation range: Surah %d, Ayahs %d-%d", result.Surah, result.StartAyah, result.EndAyah)
+	} else if *timingsPath != "" {
+		matcher := newMatcher(cfg.QuranAPI, *expectedSurah, logger)
+		passages, err = timingFilePassages(ctx, *timingsPath, *timingsFormat, matcher)
+		if err != nil {
+			exitWithError(err)
+		}
+		for _, p := range passages {
+			logger.Infof("Using timing file range: %s", formatPassage(p))
+		}
+		result = passages[0].Result
+	} else if *passagesFlag {
+		gap := time.Duration(*passageGap * float64(time.Second))
+		passages, err = identifyPassages(ctx, cfg, *audioPath, *expectedSurah, gap, logger)
+		if err != nil {
+			exitWithError(err)
+		}
+		for i, p := range passages {
+			logger.Infof("Passage %d: %s", i+1, formatPassage(p))
+		}
+		result = passages[0].Result
 	} else {
-		recognizer := recognize.NewWhisperRecognizer(cfg.Audio.WhisperCmd)
+		recognizer := newTranscriber(cfg.Audio, logger)
 		if !recognizer.Available() {
-			exitWithError(fmt.Errorf("whisper not available"))
+			exitWithError(fmt.Errorf("%s not available", alignerName(cfg.Audio)))
 		}
-		matcher := recognize.Matcher{
-			Corpus:        &recognize.APICorpus{BaseURL: cfg.QuranAPI.BaseURL, Edition: cfg.QuranAPI.Edition, Timeout: time.Duration(cfg.QuranAPI.TimeoutSec) * time.Second},
-			ExpectedSurah: *expectedSurah,
-		}
-		detected, transcript, err := recognizer.Identify(ctx, *audioPath, cfg.Audio.Language, &matcher)
+		matcher := newMatcher(cfg.QuranAPI, *expectedSurah, logger)
+		detected, transcript, err := recognize.Identify(ctx, recognizer, *audioPath, cfg.Audio.Language, matcher)
 		if err != nil {
 			logger.Warnf("Identify failed: %v", err)
 			if transcript != "" {
@@ -131,39 +206,95 @@ func generateAudioCmd(args []string) {
 		BackgroundPath:     *backgroundPath,
 		NoBackground:       *noBackground,
 		AudioPath:          *audioPath,
-	}
-	if err := runGenerate(opts); err != nil {
+		NoCache:            *noCache,
+		TimingsPath:        *timingsPath,
+		TimingsFormat:      *timingsFormat,
+		ExportTimings:      *exportTimings,
+	}
+	if len(passages) > 1 {
+		opts.Passages = passages
+	}
+	if layout == "background" || layout == "pip" {
+		opts.SourceVideo = source.VideoPath
+		opts.VideoLayout = layout
+		opts.VideoCrop = crop
+		opts.PipPosition = strings.ToLower(strings.TrimSpace(*pipPosition))
+		opts.PipScale = *pipScale
+	}
+	if err := runGenerate(ctx, opts); err != nil {
 		exitWithError(err)
 	}
 }
 
-func identifyCmd(args []string) {
+func identifyCmd(ctx context.Context, args []string) {
 	fs := flag.NewFlagSet("identify", flag.ExitOnError)
 	audioPath := fs.String("audio", "", "Recitation audio file")
+	inputPath := fs.String("input", "", "Recitation audio or video file")
 	expectedSurah := fs.Int("expected-surah", 0, "Optional expected surah number (1-114)")
 	configPath := fs.String("config", "", "Config file path")
+	passagesFlag := fs.Bool("passages", false, "List every passage (surah changes) with its time range")
+	passageGap := fs.Float64("passage-gap", 2, "Minimum pause in seconds between passage segments")
+	jsonOut := fs.Bool("json", false, "Print ranked candidates and ayah times as JSON")
+	top := fs.Int("top", 1, "Number of ranked candidates to print")
 	_ = fs.Parse(args)
-	if *audioPath == "" {
-		exitWithError(fmt.Errorf("audio path is required"))
+	if *audioPath == "" && *inputPath == "" {
+		exitWithError(fmt.Errorf("audio or input path is required"))
 	}
 	cfg, created, err := loadConfig(*configPath)
 	if err != nil {
 		exitWithError(err)
 	}
 	logger := utils.NewLogger(cfg.Logging.Level)
+	if *jsonOut {
+		// Keep stdout for the JSON document.
+		logger.SetOutput(os.Stderr)
+	}
 	if created {
 		logger.Infof("Created default config at %s", resolveConfigPath(*configPath))
 	}
-	recognizer := recognize.NewWhisperRecognizer(cfg.Audio.WhisperCmd)
+	recognizer := newTranscriber(cfg.Audio, logger)
 	if !recognizer.Available() {
-		exitWithError(fmt.Errorf("whisper not available"))
+		exitWithError(fmt.Errorf("%s not available", alignerName(cfg.Audio)))
 	}
-	ctx := context.Background()
-	matcher := recognize.Matcher{
-		Corpus:        &recognize.APICorpus{BaseURL: cfg.QuranAPI.BaseURL, Edition: cfg.QuranAPI.Edition, Timeout: time.Duration(cfg.QuranAPI.TimeoutSec) * time.Second},
-		ExpectedSurah: *expectedSurah,
+	if *inputPath != "" {
+		source, err := prepareRecitationInput(ctx, *inputPath, cfg.Output.TempDir, cfg.Audio.BitrateKbps, logger)
+		if err != nil {
+			exitWithError(err)
+		}
+		*audioPath = source.AudioPath
 	}
-	result, transcript, err := recognizer.Identify(ctx, *audioPath, cfg.Audio.Language, &matcher)
+	if *passagesFlag {
+		passages, err := identifyPassages(ctx, cfg, *audioPath, *expectedSurah, time.Duration(*passageGap*float64(time.Second)), logger)
+		if err != nil {
+			exitWithError(err)
+		}
+		for _, p := range passages {
+			fmt.Println(formatPassage(p))
+		}
+		return
+	}
+	matcher := newMatcher(cfg.QuranAPI, *expectedSurah, logger)
+	if *jsonOut {
+		if err := writeIdentifyJSON(ctx, os.Stdout, cfg, matcher, *audioPath, *top, logger); err != nil {
+			exitWithError(err)
+		}
+		return
+	}
+	if *top > 1 {
+		transcript, err := recognizer.Transcribe(ctx, *audioPath, cfg.Audio.Language)
+		if err != nil {
+			exitWithError(err)
+		}
+		candidates, err := matcher.Candidates(ctx, transcript, *top)
+		if err != nil {
+			exitWithError(err)
+		}
+		for i, c := range candidates {
+			fmt.Printf("%d. %s\n", i+1, formatCandidate(c))
+		}
+		return
+	}
+	result, transcript, err := recognize.Identify(ctx, recognizer, *audioPath, cfg.Audio.Language, matcher)
 	if err != nil {
 		logger.Warnf("Identify failed: %v", err)
 		if transcript != "" {
@@ -185,28 +316,60 @@ type generateOptions struct {
 	BackgroundPath     string
 	NoBackground       bool
 	AudioPath          string
-}
-
-func generateCmd(args []string) {
+	NoCache            bool
+	// TimingsPath renders from an edited timing file instead of analysing the audio.
+	TimingsPath   string
+	TimingsFormat string
+	ExportTimings string
+	// RangeFromTimings takes the passage range from TimingsPath instead of
+	// Surah, StartAyah and EndAyah.
+	RangeFromTimings bool
+	// Passages renders several identified ranges of one recording in order.
+	Passages []recognize.Passage
+	// SourceVideo is the recitation video reused by VideoLayout (background or pip).
+	SourceVideo string
+	VideoLayout string
+	VideoCrop   string
+	PipPosition string
+	PipScale    float64
+}
+
+func generateCmd(ctx context.Context, args []string) {
 	fs := flag.NewFlagSet("generate", flag.ExitOnError)
 	opts := generateOptions{}
 	fs.IntVar(&opts.Surah, "surah", 1, "Surah number (1-114)")
 	fs.IntVar(&opts.StartAyah, "start", 1, "Start ayah in surah")
 	fs.IntVar(&opts.EndAyah, "end", 1, "End ayah in surah")
-	fs.StringVar(&opts.Mode, "mode", "sequential", "Display mode: sequential|word-by-word")
+	fs.StringVar(&opts.Mode, "mode", "sequential", "Display mode: sequential|word-by-word|2x2|chunk:N|chunk:auto|phrase|line-by-line|karaoke|progressive|teleprompter")
 	fs.StringVar(&opts.Output, "output", "", "Output video path")
 	fs.StringVar(&opts.ConfigPath, "config", "", "Config file path")
 	fs.BoolVar(&opts.IncludeTranslation, "translation", true, "Include translation overlay")
 	fs.StringVar(&opts.BackgroundPath, "background", "", "Custom background video path")
 	fs.BoolVar(&opts.NoBackground, "no-background", false, "Disable background video (solid color)")
+	fs.BoolVar(&opts.NoCache, "no-cache", false, "Ignore cached alignments and re-run transcription")
+	fs.StringVar(&opts.TimingsPath, "timings", "", "Render from a timings file (JSON, SRT, LRC, Audacity labels, Whisper JSON) instead of aligning")
+	fs.StringVar(&opts.TimingsFormat, "timings-format", "auto", "Timings file format: auto|json|srt|lrc|audacity|whisper")
+	fs.StringVar(&opts.ExportTimings, "export-timings", "", "Write the final timings to a JSON file")
 	_ = fs.Parse(args)
+	if opts.TimingsPath != "" {
+		opts.RangeFromTimings = true
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "surah", "start", "end":
+				opts.RangeFromTimings = false
+			}
+		})
+	}
 
-	if err := runGenerate(opts); err != nil {
+	if err := runGenerate(ctx, opts); err != nil {
 		exitWithError(err)
 	}
 }
 
-func runGenerate(opts generateOptions) error {
+func runGenerate(ctx context.Context, opts generateOptions) error {
+	if _, err := config.ParseDisplayMode(opts.Mode); err != nil {
+		return err
+	}
 	cfg, created, err := loadConfig(opts.ConfigPath)
 	if err != nil {
 		return err
@@ -215,6 +378,15 @@ func runGenerate(opts generateOptions) error {
 	if created {
 		logger.Infof("Created default config at %s", resolveConfigPath(opts.ConfigPath))
 	}
+	if opts.NoCache {
+		cfg.Audio.AlignCache = false
+	}
+	if opts.RangeFromTimings {
+		if err := applyTimingFileRange(ctx, &opts, newMatcher(cfg.QuranAPI, 0, logger)); err != nil {
+			return err
+		}
+		logger.Infof("Using timing file range: Surah %d, Ayahs %d-%d", opts.Surah, opts.StartAyah, opts.EndAyah)
+	}
 	var aiClient *ai.Client
 	if cfg.AI.Enabled {
 		aiClient = &ai.Client{
@@ -233,16 +405,33 @@ func runGenerate(opts generateOptions) error {
 	}
 
 	if opts.Output == "" {
-		outputName := fmt.Sprintf("surah%d_%d-%d_%s.mp4", opts.Surah, opts.StartAyah, opts.EndAyah, strings.ReplaceAll(opts.Mode, " ", "-"))
+		outputName := fmt.Sprintf("surah%d_%d-%d_%s.mp4", opts.Surah, opts.StartAyah, opts.EndAyah, modeFileName(opts.Mode))
+		if n := len(opts.Passages); n > 1 {
+			outputName = fmt.Sprintf("passages%d_surah%d-%d_%s.mp4", n, opts.Passages[0].Surah, opts.Passages[n-1].Surah, modeFileName(opts.Mode))
+		}
 		opts.Output = filepath.Join(cfg.Output.Dir, outputName)
 	}
 
-	ctx := context.Background()
-	logger.Infof("Fetching verses: Surah %d, ayahs %d-%d", opts.Surah, opts.StartAyah, opts.EndAyah)
 	client := quran.NewClient(cfg.QuranAPI.BaseURL, time.Duration(cfg.QuranAPI.TimeoutSec)*time.Second)
-	verses, err := client.FetchVerses(ctx, opts.Surah, opts.StartAyah, opts.EndAyah, cfg.QuranAPI.Edition, cfg.QuranAPI.Translation)
-	if err != nil {
-		return err
+	var (
+		verses        []quran.Verse
+		passageVerses [][]quran.Verse
+	)
+	if len(opts.Passages) > 0 {
+		logger.Infof("Fetching verses for %d passages", len(opts.Passages))
+		passageVerses, err = fetchPassageVerses(ctx, client, cfg.QuranAPI, opts.Passages)
+		if err != nil {
+			return err
+		}
+		for _, pv := range passageVerses {
+			verses = append(verses, pv...)
+		}
+	} else {
+		logger.Infof("Fetching verses: Surah %d, ayahs %d-%d", opts.Surah, opts.StartAyah, opts.EndAyah)
+		verses, err = client.FetchVerses(ctx, opts.Surah, opts.StartAyah, opts.EndAyah, cfg.QuranAPI.Edition, cfg.QuranAPI.Translation)
+		if err != nil {
+			return err
+		}
 	}
 
 	ayahNumbers := make([]int, len(verses))
@@ -265,7 +454,11 @@ func runGenerate(opts generateOptions) error {
 		}
 		audioPath = opts.AudioPath
 		logger.Infof("Using recitation audio: %s", audioPath)
-		if cfg.Audio.TrimSilence {
+		if cfg.Audio.TrimSilence && opts.SourceVideo != "" {
+			logger.Warnf("Skipping silence trimming to keep the input video in sync")
+		} else if cfg.Audio.TrimSilence && len(opts.Passages) > 0 && opts.TimingsPath == "" {
+			logger.Warnf("Skipping silence trimming to keep passage times in sync")
+		} else if cfg.Audio.TrimSilence {
 			trimmed := filepath.Join(tempDir, "recitation_trim.mp3")
 			if err := audio.TrimSilence(ctx, audioPath, trimmed, cfg.Audio.BitrateKbps, cfg.Audio.SilenceDB, cfg.Audio.SilenceSec); err == nil {
 				audioPath = trimmed
@@ -310,54 +503,46 @@ func runGenerate(opts generateOptions) error {
 		}
 	}
 
-	logger.Infof("Preparing timings")
-	timings, err := render.BuildTimings(verses, segments)
+	var timings []render.Timing
+	if opts.TimingsPath != "" {
+		logger.Infof("Loading timings: %s", opts.TimingsPath)
+		timings, err = loadTimingFile(opts.TimingsPath, opts.TimingsFormat, verses, audioDuration, logger)
+	} else if len(opts.Passages) > 0 {
+		timings, err = passageTimings(ctx, &opts, cfg, opts.Passages, passageVerses, audioPath, logger)
+	} else {
+		timings, err = analyzeTimings(ctx, &opts, cfg, verses, segments, audioPath, audioDuration, logger)
+	}
 	if err != nil {
 		return err
 	}
-	mode := strings.ToLower(opts.Mode)
-	repeatPairs := isRepeatPairsMode(mode)
-	if isRepeatMode(mode) && opts.AudioPath != "" {
-		repeatTimings, err := buildRepeatTimings(ctx, verses, audioPath, audioDuration, cfg.Audio, logger, repeatPairs)
-		if err != nil {
-			logger.Warnf("Repeat mode failed: %v; falling back to sequential", err)
-			mode = "sequential"
-			opts.Mode = "sequential"
-		} else {
-			timings = repeatTimings
-		}
-	}
-	if !repeatPairs && (mode == "word-by-word" || mode == "word" || mode == "two-by-two" || mode == "two" || mode == "pair" || mode == "2x2") {
-		if opts.AudioPath != "" {
-			_ = applyWordAlignmentFullAudio(ctx, timings, audioPath, cfg.Audio, logger)
-		} else {
-			_ = applyWordAlignment(ctx, timings, segments, audioPath, cfg.Audio, logger)
-		}
-	}
-	if mode == "word-by-word" || mode == "word" || mode == "two-by-two" || mode == "two" || mode == "pair" || mode == "2x2" || repeatPairs {
-		normalizeWordTimings(timings)
-	}
-	if opts.AudioPath != "" && mode == "sequential" {
-		if applyWordAlignmentFullAudio(ctx, timings, audioPath, cfg.Audio, logger) {
-			if applyAyahBoundariesFromWordTimings(timings) {
-				logger.Infof("Aligned ayah boundaries to recitation audio")
-			}
+	if opts.ExportTimings != "" {
+		if err := render.WriteTimingFile(opts.ExportTimings, timings); err != nil {
+			return err
 		}
+		logger.Infof("Exported timings: %s", opts.ExportTimings)
 	}
-	if mode == "sequential" && cfg.Audio.PauseSensitive {
-		ensureWordTimings(ctx, opts.AudioPath != "", timings, segments, audioPath, cfg.Audio, logger)
-		silences, err := audio.DetectSilences(ctx, audioPath, cfg.Audio.PauseDB, cfg.Audio.PauseSec)
-		if err != nil {
-			logger.Warnf("Pause-sensitive display failed: %v", err)
-		} else if len(silences) > 0 {
-			timings = splitTimingsOnSilence(timings, silences, 120*time.Millisecond)
-		}
-	} else if mode == "sequential" {
-		ensureContinuousTimings(timings, audioDuration)
+	if err := checkAlignmentConfidence(timings, opts.Output, cfg.Audio, logger); err != nil {
+		return err
 	}
 
 	bgPath := ""
-	if opts.BackgroundPath != "" {
+	bgCrop := ""
+	var inset *render.Inset
+	switch opts.VideoLayout {
+	case "background":
+		bgPath = opts.SourceVideo
+		bgCrop = opts.VideoCrop
+		logger.Infof("Using input video as background: %s", bgPath)
+	case "pip":
+		inset = &render.Inset{
+			Path:     opts.SourceVideo,
+			Crop:     opts.VideoCrop,
+			Scale:    opts.PipScale,
+			Position: opts.PipPosition,
+		}
+		logger.Infof("Using input video as picture-in-picture inset")
+	}
+	if bgPath == "" && opts.BackgroundPath != "" {
 		totalDuration := timings[len(timings)-1].End
 		resolved, err := resolveBackgroundInput(ctx, opts.BackgroundPath, tempDir, time.Duration(cfg.QuranAPI.TimeoutSec)*time.Second, logger, totalDuration)
 		if err != nil {
@@ -365,7 +550,7 @@ func runGenerate(opts generateOptions) error {
 		}
 		bgPath = resolved
 		logger.Infof("Using custom background: %s", bgPath)
-	} else if !opts.NoBackground {
+	} else if bgPath == "" && !opts.NoBackground {
 		bgTimeoutSec := cfg.Background.TimeoutSec
 		if bgTimeoutSec <= 0 {
 			bgTimeoutSec = cfg.QuranAPI.TimeoutSec
@@ -404,7 +589,7 @@ func runGenerate(opts generateOptions) error {
 				}
 			}
 
-			if cfg.Background.PerAyah && strings.EqualFold(opts.Mode, "sequential") {
+			if cfg.Background.PerAyah && strings.EqualFold(opts.Mode, config.ModeSequential) {
 				width, height, err := render.ParseResolution(cfg.Video.Resolution)
 				if err != nil {
 					logger.Warnf("Invalid resolution for per-ayah background: %v; falling back to single background", err)
@@ -469,8 +654,7 @@ func runGenerate(opts generateOptions) error {
 		}
 	}
 
-	logger.Infof("Rendering video")
-	err = render.Render(ctx, render.RenderInput{
+	input, err := checkGlyphs(render.RenderInput{
 		Timings:            timings,
 		AudioPath:          audioPath,
 		BackgroundPath:     bgPath,
@@ -479,15 +663,21 @@ func runGenerate(opts generateOptions) error {
 		Mode:               opts.Mode,
 		VideoConfig:        cfg.Video,
 		IncludeTranslation: opts.IncludeTranslation,
-	})
+		BackgroundCrop:     bgCrop,
+		Inset:              inset,
+	}, logger)
 	if err != nil {
 		return err
 	}
+	logger.Infof("Rendering video")
+	if err := render.Render(ctx, input); err != nil {
+		return err
+	}
 	logger.Infof("Video generated: %s", opts.Output)
 	return nil
 }
 
-func batchCmd(args []string) {
+func batchCmd(ctx context.Context, args []string) {
 	fs := flag.NewFlagSet("batch", flag.ExitOnError)
 	var (
 		batchFile  = fs.String("file", "", "Batch YAML file")
@@ -516,9 +706,9 @@ func batchCmd(args []string) {
 		logger.Infof("Starting batch job %d/%d", idx+1, len(b.Jobs))
 		output := job.OutputName
 		if output == "" {
-			output = fmt.Sprintf("surah%d_%d-%d_%s.mp4", job.Surah, job.StartAyah, job.EndAyah, strings.ReplaceAll(job.Mode, " ", "-"))
+			output = fmt.Sprintf("surah%d_%d-%d_%s.mp4", job.Surah, job.StartAyah, job.EndAyah, modeFileName(job.Mode))
 		}
-		err := runGenerate(generateOptions{
+		err := runGenerate(ctx, generateOptions{
 			Surah:              job.Surah,
 			StartAyah:          job.StartAyah,
 			EndAyah:            job.EndAyah,
@@ -527,6 +717,9 @@ func batchCmd(args []string) {
 			ConfigPath:         resolveConfigPath(*configPath),
 			IncludeTranslation: true,
 		})
+		if ctx.Err() != nil {
+			exitWithError(ctx.Err())
+		}
 		if err != nil {
 			logger.Warnf("Batch job %d failed: %v", idx+1, err)
 			continue
@@ -555,6 +748,34 @@ func configCmd(args []string) {
 	}
 }
 
+func cacheCmd(args []string) {
+	fs := flag.NewFlagSet("cache", flag.ExitOnError)
+	configPath := fs.String("config", "", "Config file path")
+	_ = fs.Parse(args)
+	if fs.NArg() == 0 {
+		fmt.Println("Usage: quranvideo cache clear")
+		return
+	}
+	switch fs.Arg(0) {
+	case "clear":
+		cfg, _, err := loadConfig(*configPath)
+		if err != nil {
+			exitWithError(err)
+		}
+		dir, err := alignCacheDir(cfg.Audio)
+		if err != nil {
+			exitWithError(err)
+		}
+		removed, err := align.ClearCache(dir)
+		if err != nil {
+			exitWithError(err)
+		}
+		fmt.Printf("Removed %d cached alignments from %s\n", removed, dir)
+	default:
+		fmt.Println("Unknown cache command")
+	}
+}
+
 func loadConfig(path string) (*config.Config, bool, error) {
 	resolved := resolveConfigPath(path)
 	cfg, created, err := config.LoadOrCreate(resolved)
@@ -588,10 +809,10 @@ func applyWordAlignment(ctx context.Context, timings []render.Timing, segments [
 	if mode == "even" {
 		return false
 	}
-	aligner := align.NewWhisperAligner(cfg.WhisperCmd)
+	aligner := newAligner(cfg, logger)
 	if !aligner.Available() {
 		if mode == "whisper" {
-			logger.Warnf("Whisper not available; falling back to even word timing")
+			logger.Warnf("%s not available; falling back to even word timing", alignerName(cfg))
 		}
 		return false
 	}
@@ -604,18 +825,14 @@ func applyWordAlignment(ctx context.Context, timings []render.Timing, segments [
 		if len(words) == 0 {
 			continue
 		}
-		wordTimings, err := aligner.Align(segments[i].Path, words, cfg.Language)
+		wordTimings, err := aligner.Align(ctx, segments[i].Path, words, cfg.Language)
 		if err != nil {
 			logger.Warnf("Word alignment failed for ayah %d: %v; using even split", timings[i].Verse.NumberInSurah, err)
 			continue
 		}
 		mapped := make([]render.WordTiming, 0, len(wordTimings))
 		for _, wt := range wordTimings {
-			mapped = append(mapped, render.WordTiming{
-				Word:  wt.Word,
-				Start: timings[i].Start + wt.Start,
-				End:   timings[i].Start + wt.End,
-			})
+			mapped = append(mapped, toRenderWordTiming(wt, timings[i].Start))
 		}
 		timings[i].WordTimings = mapped
 		if len(mapped) > 0 {
@@ -634,10 +851,10 @@ func applyWordAlignmentFullAudio(ctx context.Context, timings []render.Timing, a
 	if mode == "even" {
 		return false
 	}
-	aligner := align.NewWhisperAligner(cfg.WhisperCmd)
+	aligner := newAligner(cfg, logger)
 	if !aligner.Available() {
 		if mode == "whisper" {
-			logger.Warnf("Whisper not available; falling back to even word timing")
+			logger.Warnf("%s not available; falling back to even word timing", alignerName(cfg))
 		}
 		return false
 	}
@@ -653,7 +870,7 @@ func applyWordAlignmentFullAudio(ctx context.Context, timings []render.Timing, a
 	if len(words) == 0 {
 		return false
 	}
-	wordTimings, err := aligner.Align(audioPath, words, cfg.Language)
+	wordTimings, err := aligner.Align(ctx, audioPath, words, cfg.Language)
 	if err != nil {
 		logger.Warnf("Full-audio word alignment failed: %v; using even split", err)
 		return false
@@ -664,7 +881,7 @@ func applyWordAlignmentFullAudio(ctx context.Context, timings []render.Timing, a
 			break
 		}
 		vi := verseIndex[i]
-		perVerse[vi] = append(perVerse[vi], render.WordTiming{Word: wt.Word, Start: wt.Start, End: wt.End})
+		perVerse[vi] = append(perVerse[vi], toRenderWordTiming(wt, 0))
 	}
 	for i := range timings {
 		if len(perVerse[i]) > 0 {
@@ -675,22 +892,157 @@ func applyWordAlignmentFullAudio(ctx context.Context, timings []render.Timing, a
 	return true
 }
 
-func isRepeatMode(mode string) bool {
-	switch strings.ToLower(mode) {
-	case "repeat", "sequential-repeat", "repeat-2x2", "repeat-two-by-two", "repeat-pair":
-		return true
-	default:
-		return false
+// applyPauseWeightedWordTimings refines the letter-weighted fallback split by
+// snapping word boundaries to detected pauses. It is used when Whisper alignment
+// is disabled or unavailable.
+func applyPauseWeightedWordTimings(ctx context.Context, timings []render.Timing, audioPath string, cfg config.AudioConfig, logger *utils.Logger) {
+	silences, err := detectPauses(ctx, audioPath, cfg)
+	if err != nil {
+		logger.Warnf("Pause detection for word timing failed: %v; keeping weighted split", err)
+		return
+	}
+	if len(silences) == 0 {
+		return
+	}
+	for i := range timings {
+		words := strings.Fields(timings[i].Verse.Text)
+		if wts := render.SplitWordTimingsAroundPauses(words, timings[i].Start, timings[i].End, silences); len(wts) > 0 {
+			timings[i].WordTimings = wts
+		}
 	}
+	logger.Infof("Placed fallback word timings around %d detected pauses", len(silences))
 }
 
-func isRepeatPairsMode(mode string) bool {
-	switch strings.ToLower(mode) {
-	case "repeat-2x2", "repeat-two-by-two", "repeat-pair":
-		return true
-	default:
-		return false
+// resolveTimingsFormat detects the format when it is empty or auto.
+func resolveTimingsFormat(path, format string) (string, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" || format == importer.FormatAuto {
+		return importer.DetectFormat(path)
 	}
+	return format, nil
+}
+
+// loadTimingFile renders from an edited timing file, validated against the
+// verses, or from an external source (SRT, LRC, Audacity labels, Whisper JSON)
+// matched to the verse text.
+func loadTimingFile(path, format string, verses []quran.Verse, audioDuration time.Duration, logger *utils.Logger) ([]render.Timing, error) {
+	format, err := resolveTimingsFormat(path, format)
+	if err != nil {
+		return nil, err
+	}
+	var timings []render.Timing
+	if format == importer.FormatJSON {
+		tf, err := render.LoadTimingFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if timings, err = tf.Apply(verses); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	} else {
+		cues, err := importer.Load(path, format)
+		if err != nil {
+			return nil, err
+		}
+		if timings, err = importer.Map(cues, verses, audioDuration); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		logger.Infof("Imported %d %s cues into %d timings", len(cues), format, len(timings))
+	}
+	if last := timings[len(timings)-1].End; audioDuration > 0 && last > audioDuration+500*time.Millisecond {
+		logger.Warnf("Timings end at %s but audio is %s long", last, audioDuration)
+	}
+	return timings, nil
+}
+
+// analyzeTimings derives ayah and word timings from the audio: repeat
+// detection, word alignment and pause handling. It may fall back from a repeat
+// mode to sequential, updating opts.Mode.
+func analyzeTimings(ctx context.Context, opts *generateOptions, cfg *config.Config, verses []quran.Verse, segments []audio.Segment, audioPath string, audioDuration time.Duration, logger *utils.Logger) ([]render.Timing, error) {
+	logger.Infof("Preparing timings")
+	timings, err := render.BuildTimings(verses, segments)
+	if err != nil {
+		return nil, err
+	}
+	mode, err := config.ParseDisplayMode(opts.Mode)
+	if err != nil {
+		return nil, err
+	}
+	repeatPairs := mode.Repeat && mode.Kind == config.ModeChunk
+	if mode.Repeat && opts.AudioPath != "" {
+		repeatTimings, err := buildRepeatTimings(ctx, verses, audioPath, audioDuration, cfg.Audio, logger, repeatPairs)
+		if err != nil {
+			logger.Warnf("Repeat mode failed: %v; falling back to sequential", err)
+			mode = config.DisplayMode{Kind: config.ModeSequential}
+			opts.Mode = config.ModeSequential
+		} else {
+			timings = repeatTimings
+		}
+	}
+	sequential := mode == config.DisplayMode{Kind: config.ModeSequential}
+	if !repeatPairs && mode.WordTimed() {
+		aligned := false
+		if opts.AudioPath != "" {
+			aligned = applyWordAlignmentFullAudio(ctx, timings, audioPath, cfg.Audio, logger)
+		} else {
+			aligned = applyWordAlignment(ctx, timings, segments, audioPath, cfg.Audio, logger)
+		}
+		if !aligned {
+			applyPauseWeightedWordTimings(ctx, timings, audioPath, cfg.Audio, logger)
+		}
+	}
+	if mode.WordTimed() {
+		normalizeWordTimings(timings)
+	}
+	if opts.AudioPath != "" && sequential {
+		if applyWordAlignmentFullAudio(ctx, timings, audioPath, cfg.Audio, logger) {
+			if applyAyahBoundariesFromWordTimings(timings) {
+				logger.Infof("Aligned ayah boundaries to recitation audio")
+			}
+		}
+	}
+	if sequential && cfg.Audio.PauseSensitive {
+		ensureWordTimings(ctx, opts.AudioPath != "", timings, segments, audioPath, cfg.Audio, logger)
+		silences, err := detectPauses(ctx, audioPath, cfg.Audio)
+		if err != nil {
+			logger.Warnf("Pause-sensitive display failed: %v", err)
+		} else if len(silences) > 0 {
+			timings = splitTimingsOnSilence(timings, silences, 120*time.Millisecond)
+		}
+	} else if sequential {
+		ensureContinuousTimings(timings, audioDuration)
+	}
+	return timings, nil
+}
+
+// checkAlignmentConfidence logs the alignment score, writes the optional
+// report and fails when the score is below audio.min_align_confidence.
+func checkAlignmentConfidence(timings []render.Timing, output string, cfg config.AudioConfig, logger *utils.Logger) error {
+	if !render.HasAlignedWords(timings) {
+		if cfg.MinAlignConfidence > 0 {
+			logger.Warnf("No aligned word timings; skipping alignment confidence check")
+		}
+		return nil
+	}
+	report := render.BuildAlignmentReport(timings)
+	logger.Infof("Alignment score %.2f over %d words (%d low-confidence)", report.Score, report.Words, len(report.LowConfidence))
+	if cfg.AlignReport {
+		reportPath := strings.TrimSuffix(output, filepath.Ext(output)) + ".alignment.json"
+		if err := render.WriteAlignmentReport(reportPath, report); err != nil {
+			logger.Warnf("Failed to write alignment report: %v", err)
+		} else {
+			logger.Infof("Wrote alignment report: %s", reportPath)
+		}
+	}
+	if cfg.MinAlignConfidence > 0 && report.Score < cfg.MinAlignConfidence {
+		return fmt.Errorf("alignment score %.2f is below audio.min_align_confidence %.2f", report.Score, cfg.MinAlignConfidence)
+	}
+	return nil
+}
+
+// modeFileName makes a display mode safe for default output file names.
+func modeFileName(mode string) string {
+	return strings.NewReplacer(" ", "-", ":", "-").Replace(mode)
 }
 
 func ensureWordTimings(ctx context.Context, useFullAudio bool, timings []render.Timing, segments []audio.Segment, audioPath string, cfg config.AudioConfig, logger *utils.Logger) {
@@ -708,6 +1060,22 @@ func ensureWordTimings(ctx context.Context, useFullAudio bool, timings []render.
 	_ = applyWordAlignment(ctx, timings, segments, audioPath, cfg, logger)
 }
 
+// detectPauses finds pauses with the configured detector (ffmpeg silencedetect or the native VAD).
+func detectPauses(ctx context.Context, audioPath string, cfg config.AudioConfig) ([]audio.Silence, error) {
+	if strings.EqualFold(cfg.PauseDetector, "vad") {
+		vad := audio.VADConfig{
+			MinSilence: time.Duration(cfg.PauseSec * float64(time.Second)),
+			FloorDB:    float64(cfg.PauseDB),
+		}
+		result, err := audio.DetectVoiceActivity(ctx, audioPath, vad)
+		if err != nil {
+			return nil, err
+		}
+		return result.Silences, nil
+	}
+	return audio.DetectSilences(ctx, audioPath, cfg.PauseDB, cfg.PauseSec)
+}
+
 func hasWordTimings(timings []render.Timing) bool {
 	for _, t := range timings {
 		if len(t.WordTimings) > 0 {
@@ -1147,11 +1515,11 @@ type ayahMatch struct {
 }
 
 func buildRepeatTimings(ctx context.Context, verses []quran.Verse, audioPath string, audioDuration time.Duration, cfg config.AudioConfig, logger *utils.Logger, withWordTimings bool) ([]render.Timing, error) {
-	aligner := align.NewWhisperAligner(cfg.WhisperCmd)
+	aligner := newAligner(cfg, logger)
 	if !aligner.Available() {
-		return nil, fmt.Errorf("whisper not available")
+		return nil, fmt.Errorf("%s not available", alignerName(cfg))
 	}
-	whisperWords, err := aligner.TranscribeWords(audioPath, cfg.Language)
+	whisperWords, err := aligner.TranscribeWords(ctx, audioPath, cfg.Language)
 	if err != nil {
 		return nil, err
 	}
@@ -1161,7 +1529,7 @@ func buildRepeatTimings(ctx context.Context, verses []quran.Verse, audioPath str
 	if audioDuration <= 0 {
 		audioDuration = whisperWords[len(whisperWords)-1].End
 	}
-	silences, err := audio.DetectSilences(ctx, audioPath, cfg.PauseDB, cfg.PauseSec)
+	silences, err := detectPauses(ctx, audioPath, cfg)
 	if err != nil {
 		logger.Warnf("Repeat mode silence detection failed: %v", err)
 	}
@@ -1357,6 +1725,18 @@ func sliceAyahWords(words []string, start, end int) []string {
 	return words[start : end+1]
 }
 
+// toRenderWordTiming converts an aligned word, shifted by offset, keeping its provenance.
+func toRenderWordTiming(wt align.WordTiming, offset time.Duration) render.WordTiming {
+	return render.WordTiming{
+		Word:        wt.Word,
+		Start:       offset + wt.Start,
+		End:         offset + wt.End,
+		Source:      string(wt.Source),
+		Confidence:  wt.Confidence(),
+		Probability: wt.Probability,
+	}
+}
+
 func mapSegmentWordTimings(words []string, segWords []align.WordTiming) []render.WordTiming {
 	if len(words) == 0 || len(segWords) == 0 {
 		return nil
@@ -1369,7 +1749,13 @@ func mapSegmentWordTimings(words []string, segWords []align.WordTiming) []render
 	if len(words) == len(segWords) {
 		out := make([]render.WordTiming, len(words))
 		for i := range words {
-			out[i] = render.WordTiming{Word: words[i], Start: segWords[i].Start, End: segWords[i].End}
+			wt := segWords[i]
+			wt.Word = words[i]
+			wt.Source = align.SourceIndex
+			if align.NormalizeWord(words[i]) == align.NormalizeWord(segWords[i].Word) {
+				wt.Source = align.SourceMatched
+			}
+			out[i] = toRenderWordTiming(wt, 0)
 		}
 		return out
 	}
@@ -1383,7 +1769,7 @@ func mapSegmentWordTimings(words []string, segWords []align.WordTiming) []render
 	}
 	pairs := lcsIndexPairs(ayahNorm, segNorm)
 	if len(pairs) == 0 {
-		return evenSplitWordTimings(words, start, end)
+		return render.SplitWordTimings(words, start, end)
 	}
 	out := make([]render.WordTiming, len(words))
 	matched := make([]bool, len(words))
@@ -1391,35 +1777,16 @@ func mapSegmentWordTimings(words []string, segWords []align.WordTiming) []render
 		if p.i < 0 || p.i >= len(words) || p.j < 0 || p.j >= len(segWords) {
 			continue
 		}
-		out[p.i] = render.WordTiming{Word: words[p.i], Start: segWords[p.j].Start, End: segWords[p.j].End}
+		wt := segWords[p.j]
+		wt.Word = words[p.i]
+		wt.Source = align.SourceMatched
+		out[p.i] = toRenderWordTiming(wt, 0)
 		matched[p.i] = true
 	}
 	fillMissingWordTimings(out, matched, start, end)
 	return out
 }
 
-func evenSplitWordTimings(words []string, start, end time.Duration) []render.WordTiming {
-	if len(words) == 0 || end <= start {
-		return nil
-	}
-	per := (end - start) / time.Duration(len(words))
-	if per <= 0 {
-		return nil
-	}
-	out := make([]render.WordTiming, len(words))
-	cursor := start
-	for i := range words {
-		s := cursor
-		e := s + per
-		if i == len(words)-1 {
-			e = end
-		}
-		out[i] = render.WordTiming{Word: words[i], Start: s, End: e}
-		cursor = e
-	}
-	return out
-}
-
 type lcsPair struct{ i, j int }
 
 func lcsIndexPairs(a, b []string) []lcsPair {
@@ -1510,6 +1877,9 @@ func fillWordGap(timings []render.WordTiming, matched []bool, prevIdx, nextIdx i
 		}
 		timings[i].Start = s
 		timings[i].End = e
+		timings[i].Source = string(align.SourceInterpolated)
+		timings[i].Confidence = 0
+		timings[i].Probability = 0
 		matched[i] = true
 		cursor = e
 	}
@@ -1,11 +1,18 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"qgencodex/internal/ffmpeg"
 	"qgencodex/internal/quran"
+	"qgencodex/internal/recognize"
 	"qgencodex/internal/render"
+	"qgencodex/internal/tasmee"
 )
 
 func TestApplyAyahBoundariesFromWordTimings(t *testing.T) {
@@ -51,3 +58,82 @@ func TestEnsureContinuousTimings(t *testing.T) {
 		t.Fatalf("expected last end to match total")
 	}
 }
+
+func TestResolveVideoCrop(t *testing.T) {
+	video := ffmpeg.Stream{CodecType: "video", Width: 1920, Height: 1080, Rotation: 90}
+	got, err := resolveVideoCrop("1080:1080:0:420", video)
+	if err != nil {
+		t.Fatalf("expected crop to fit rotated frame, got %v", err)
+	}
+	if got != "1080:1080:0:420" {
+		t.Fatalf("unexpected crop: %s", got)
+	}
+	if _, err := resolveVideoCrop("1920:1080:0:0", video); err == nil {
+		t.Fatalf("expected crop wider than rotated frame to fail")
+	}
+	if _, err := resolveVideoCrop("10:abc:0:0", video); err == nil {
+		t.Fatalf("expected invalid crop to fail")
+	}
+}
+
+func TestCheckTimingsHighlightsMistakes(t *testing.T) {
+	verses := []quran.Verse{{NumberInSurah: 1, Text: "a b c"}}
+	report := tasmee.Report{
+		Words: []tasmee.Word{
+			{Ayah: 1, Index: 1, Text: "a", End: 1 * time.Second, Kind: tasmee.Correct},
+			{Ayah: 1, Index: 2, Text: "b", Start: 2 * time.Second, End: 3 * time.Second, Kind: tasmee.Substituted},
+			{Ayah: 1, Index: 3, Text: "c", Start: 3 * time.Second, End: 3 * time.Second, Kind: tasmee.Skipped},
+		},
+		Issues: []tasmee.Issue{
+			{Kind: tasmee.Repeated, Ayah: 1, Word: 1, Expected: "a", Recited: "a", Start: 1 * time.Second, End: 2 * time.Second},
+		},
+	}
+	timings := checkTimings(verses, report)
+	if len(timings) != 1 || len(timings[0].WordTimings) != 3 || timings[0].End != 3*time.Second {
+		t.Fatalf("unexpected timings: %+v", timings)
+	}
+	words := timings[0].WordTimings
+	if words[0].Color != "" || words[1].Color != mistakeColors[tasmee.Repeated] || words[2].Color != mistakeColors[tasmee.Substituted] {
+		t.Fatalf("unexpected colors: %+v", words)
+	}
+}
+
+func TestWriteCheckJSON(t *testing.T) {
+	report := tasmee.Report{
+		Words:  []tasmee.Word{{Ayah: 1, Index: 1, Text: "a", Kind: tasmee.Correct}, {Ayah: 1, Index: 2, Text: "b", Kind: tasmee.Substituted}},
+		Issues: []tasmee.Issue{{Kind: tasmee.Substituted, Surah: 1, Ayah: 1, Word: 2, Expected: "b", Recited: "c", Start: 1500 * time.Millisecond}},
+	}
+	var buf bytes.Buffer
+	if err := writeCheckJSON(&buf, recognize.Result{Surah: 1, StartAyah: 1, EndAyah: 1}, report); err != nil {
+		t.Fatalf("writeCheckJSON failed: %v", err)
+	}
+	var out checkOutput
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("expected a JSON document, got %q: %v", buf.String(), err)
+	}
+	if out.Words != 2 || out.Correct != 1 || len(out.Issues) != 1 || out.Issues[0].Recited != "c" || out.Issues[0].Start != 1.5 {
+		t.Fatalf("unexpected report: %+v", out)
+	}
+}
+
+func TestApplyTimingFileRange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "timings.json")
+	var timings []render.Timing
+	for _, ayah := range []int{255, 256, 257} {
+		timings = append(timings, render.Timing{
+			Verse: quran.Verse{NumberInSurah: ayah, Text: "a", SurahMeta: quran.SurahMeta{Number: 2}},
+			Start: time.Duration(ayah) * time.Second,
+			End:   time.Duration(ayah+1) * time.Second,
+		})
+	}
+	if err := render.WriteTimingFile(path, timings); err != nil {
+		t.Fatalf("write timings: %v", err)
+	}
+	opts := generateOptions{Surah: 1, StartAyah: 1, EndAyah: 1, TimingsPath: path, TimingsFormat: "auto"}
+	if err := applyTimingFileRange(context.Background(), &opts, nil); err != nil {
+		t.Fatalf("applyTimingFileRange failed: %v", err)
+	}
+	if opts.Surah != 2 || opts.StartAyah != 255 || opts.EndAyah != 257 || opts.Passages != nil {
+		t.Fatalf("expected Surah 2, Ayahs 255-257, got %+v", opts)
+	}
+}
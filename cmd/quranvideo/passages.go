@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"qgencodex/internal/config"
+	"qgencodex/internal/importer"
+	"qgencodex/internal/quran"
+	"qgencodex/internal/recognize"
+	"qgencodex/internal/render"
+	"qgencodex/internal/utils"
+)
+
+// identifyPassages transcribes the recording with word timestamps and splits
+// it into identified passages at pauses of at least gap.
+func identifyPassages(ctx context.Context, cfg *config.Config, audioPath string, expectedSurah int, gap time.Duration, logger *utils.Logger) ([]recognize.Passage, error) {
+	aligner := newAligner(cfg.Audio, logger)
+	if !aligner.Available() {
+		return nil, fmt.Errorf("%s not available", alignerName(cfg.Audio))
+	}
+	words, err := aligner.TranscribeWords(ctx, audioPath, cfg.Audio.Language)
+	if err != nil {
+		return nil, err
+	}
+	return recognize.IdentifyPassages(ctx, words, newMatcher(cfg.QuranAPI, expectedSurah, logger), gap)
+}
+
+// timingFilePassages finds the recited ranges for a timings file: native files
+// carry them (several when the file spans passages), external sources are
+// identified from their text.
+func timingFilePassages(ctx context.Context, path, format string, matcher *recognize.Matcher) ([]recognize.Passage, error) {
+	format, err := resolveTimingsFormat(path, format)
+	if err != nil {
+		return nil, err
+	}
+	if format == importer.FormatJSON {
+		tf, err := render.LoadTimingFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var passages []recognize.Passage
+		for _, r := range tf.Ranges() {
+			passages = append(passages, recognize.Passage{Result: recognize.Result{Surah: r.Surah, StartAyah: r.Start, EndAyah: r.End}})
+		}
+		return passages, nil
+	}
+	cues, err := importer.Load(path, format)
+	if err != nil {
+		return nil, err
+	}
+	if importer.AyahLabelsOnly(cues) {
+		return nil, fmt.Errorf("%s has only ayah number labels; pass --surah, --start and --end", path)
+	}
+	result, err := matcher.Identify(ctx, importer.Text(cues))
+	if err != nil {
+		return nil, err
+	}
+	return []recognize.Passage{{Result: result}}, nil
+}
+
+// applyTimingFileRange sets the passage range of opts, and its passages when
+// there are several, from opts.TimingsPath.
+func applyTimingFileRange(ctx context.Context, opts *generateOptions, matcher *recognize.Matcher) error {
+	passages, err := timingFilePassages(ctx, opts.TimingsPath, opts.TimingsFormat, matcher)
+	if err != nil {
+		return err
+	}
+	if len(passages) == 0 {
+		return fmt.Errorf("%s has no ayahs", opts.TimingsPath)
+	}
+	opts.Surah, opts.StartAyah, opts.EndAyah = passages[0].Surah, passages[0].StartAyah, passages[0].EndAyah
+	opts.Passages = nil
+	if len(passages) > 1 {
+		opts.Passages = passages
+	}
+	return nil
+}
+
+func formatPassage(p recognize.Passage) string {
+	text := fmt.Sprintf("Surah %d, Ayahs %d-%d", p.Surah, p.StartAyah, p.EndAyah)
+	if p.End > p.Start {
+		text += fmt.Sprintf(" (%s-%s)", formatClock(p.Start), formatClock(p.End))
+	}
+	return text
+}
+
+func formatClock(d time.Duration) string {
+	d = d.Round(time.Second)
+	h, m, s := int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
+
+// fetchPassageVerses fetches the ayahs of every passage, in passage order.
+func fetchPassageVerses(ctx context.Context, client *quran.Client, cfg config.QuranAPIConfig, passages []recognize.Passage) ([][]quran.Verse, error) {
+	out := make([][]quran.Verse, 0, len(passages))
+	for _, p := range passages {
+		verses, err := client.FetchVerses(ctx, p.Surah, p.StartAyah, p.EndAyah, cfg.Edition, cfg.Translation)
+		if err != nil {
+			return nil, fmt.Errorf("passage %s: %w", formatPassage(p), err)
+		}
+		out = append(out, verses)
+	}
+	return out, nil
+}
+
+// passageTimings aligns each passage's ayahs to the words transcribed in its
+// part of the recording. Gaps between passages show no text.
+func passageTimings(ctx context.Context, opts *generateOptions, cfg *config.Config, passages []recognize.Passage, verses [][]quran.Verse, audioPath string, logger *utils.Logger) ([]render.Timing, error) {
+	mode, err := config.ParseDisplayMode(opts.Mode)
+	if err != nil {
+		return nil, err
+	}
+	if mode.Repeat {
+		logger.Warnf("Repeat modes are not supported with multiple passages; using sequential")
+		mode = config.DisplayMode{Kind: config.ModeSequential}
+		opts.Mode = config.ModeSequential
+	}
+	var timings []render.Timing
+	for i, p := range passages {
+		pt, err := importer.MapWords(p.Words, verses[i])
+		if err != nil {
+			return nil, fmt.Errorf("passage %s: %w", formatPassage(p), err)
+		}
+		timings = append(timings, pt...)
+	}
+	applyWordOffset(timings, computeWordOffset(ctx, audioPath, timings, cfg.Audio, logger))
+	if !mode.WordTimed() {
+		if cfg.Audio.PauseSensitive {
+			silences, err := detectPauses(ctx, audioPath, cfg.Audio)
+			if err != nil {
+				logger.Warnf("Pause-sensitive display failed: %v", err)
+			} else if len(silences) > 0 {
+				timings = splitTimingsOnSilence(timings, silences, 120*time.Millisecond)
+			}
+		}
+	} else {
+		normalizeWordTimings(timings)
+	}
+	return timings, nil
+}
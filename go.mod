@@ -3,3 +3,8 @@ module qgencodex
 go 1.21
 
 require gopkg.in/yaml.v3 v3.0.1
+
+require (
+	golang.org/x/image v0.18.0
+	golang.org/x/text v0.16.0 // indirect
+)
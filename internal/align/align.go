@@ -1,14 +1,103 @@
 package align
 
-import "time"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Source records how a word's timing was obtained.
+type Source string
+
+const (
+	// SourceMatched words matched a transcribed word.
+	SourceMatched Source = "matched"
+	// SourceInterpolated words were placed between matched neighbours.
+	SourceInterpolated Source = "interpolated"
+	// SourceIndex words were paired with a transcribed word by position only.
+	SourceIndex Source = "index"
+)
 
 type WordTiming struct {
 	Word  string
 	Start time.Duration
 	End   time.Duration
+	// Source is empty for raw transcription output.
+	Source Source
+	// Probability is the recognizer's word probability (0 when unknown).
+	Probability float64
+}
+
+// Confidence scores a single aligned word from 0 to 1.
+func (w WordTiming) Confidence() float64 {
+	p := w.Probability
+	if p <= 0 || p > 1 {
+		p = 1
+	}
+	switch w.Source {
+	case SourceMatched:
+		return p
+	case SourceIndex:
+		return 0.5 * p
+	default:
+		return 0
+	}
+}
+
+// Score is the mean word confidence of an alignment.
+func Score(words []WordTiming) float64 {
+	if len(words) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, w := range words {
+		total += w.Confidence()
+	}
+	return total / float64(len(words))
 }
 
 type Aligner interface {
-	Align(audioPath string, words []string, language string) ([]WordTiming, error)
+	Align(ctx context.Context, audioPath string, words []string, language string) ([]WordTiming, error)
 	Available() bool
 }
+
+// Backend is a speech recognizer that can align known text and also
+// transcribe free recitation with word timestamps.
+type Backend interface {
+	Aligner
+	TranscribeWords(ctx context.Context, audioPath string, language string) ([]WordTiming, error)
+}
+
+// AlignWords maps expected words onto already transcribed words (for example
+// imported from another tool) using the same strategies as the aligners.
+func AlignWords(words []string, transcribed []WordTiming) ([]WordTiming, error) {
+	if len(words) == 0 {
+		return nil, errors.New("no words to align")
+	}
+	return alignWords(words, wordTimingsToFlat(transcribed))
+}
+
+// ParseTranscriptJSON reads word timestamps from Whisper-style JSON
+// (segments[].words[] or a top-level words array) or whisper.cpp JSON
+// (transcription[]).
+func ParseTranscriptJSON(data []byte) ([]WordTiming, error) {
+	var probe struct {
+		Transcription json.RawMessage `json:"transcription"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, err
+	}
+	if len(probe.Transcription) > 0 {
+		words, err := parseWhisperCppJSON(data)
+		if err != nil {
+			return nil, err
+		}
+		return flatToWordTimings(words), nil
+	}
+	var result httpTranscription
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return flatToWordTimings(result.flatWords()), nil
+}
@@ -0,0 +1,189 @@
+package align
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// cacheVersion is bumped when the entry layout or alignment output changes.
+const cacheVersion = 2
+
+// transcriber is implemented by the built-in backends; the cache uses it to
+// keep raw transcriptions separately from alignments.
+type transcriber interface {
+	transcribe(ctx context.Context, audioPath string, language string, prompt string) ([]flatWord, error)
+}
+
+// CachedAligner stores alignment results on disk, keyed by audio content,
+// word list, language and backend settings, so re-renders skip transcription.
+type CachedAligner struct {
+	Backend Backend
+	Dir     string
+	// Settings fingerprints the backend configuration (command, model, URL...).
+	Settings string
+}
+
+func NewCachedAligner(backend Backend, dir string, settings string) *CachedAligner {
+	return &CachedAligner{Backend: backend, Dir: dir, Settings: settings}
+}
+
+type cacheEntry struct {
+	Version int          `json:"version"`
+	Aligned []WordTiming `json:"aligned,omitempty"`
+	Raw     []WordTiming `json:"raw,omitempty"`
+}
+
+func (c *CachedAligner) Available() bool {
+	return c.Backend != nil && c.Backend.Available()
+}
+
+func (c *CachedAligner) Align(ctx context.Context, audioPath string, words []string, language string) ([]WordTiming, error) {
+	if len(words) == 0 {
+		return nil, errors.New("no words to align")
+	}
+	audioHash, err := hashFile(audioPath)
+	if err != nil {
+		return nil, err
+	}
+	alignKey := c.key("align", audioHash, language, strings.Join(words, "\x1f"))
+	if entry, ok := c.load(alignKey); ok && len(entry.Aligned) == len(words) {
+		return entry.Aligned, nil
+	}
+	t, ok := c.Backend.(transcriber)
+	if !ok {
+		aligned, err := c.Backend.Align(ctx, audioPath, words, language)
+		if err != nil {
+			return nil, err
+		}
+		c.store(alignKey, cacheEntry{Aligned: aligned})
+		return aligned, nil
+	}
+	prompt := strings.TrimSpace(strings.Join(words, " "))
+	rawKey := c.key("raw", audioHash, language, prompt)
+	var raw []flatWord
+	if entry, ok := c.load(rawKey); ok && len(entry.Raw) > 0 {
+		raw = wordTimingsToFlat(entry.Raw)
+	} else {
+		raw, err = t.transcribe(ctx, audioPath, language, prompt)
+		if err != nil {
+			return nil, err
+		}
+		c.store(rawKey, cacheEntry{Raw: flatToWordTimings(raw)})
+	}
+	aligned, err := alignWords(words, raw)
+	if err != nil {
+		return nil, err
+	}
+	c.store(alignKey, cacheEntry{Aligned: aligned, Raw: flatToWordTimings(raw)})
+	return aligned, nil
+}
+
+func (c *CachedAligner) TranscribeWords(ctx context.Context, audioPath string, language string) ([]WordTiming, error) {
+	audioHash, err := hashFile(audioPath)
+	if err != nil {
+		return nil, err
+	}
+	key := c.key("transcribe", audioHash, language, "")
+	if entry, ok := c.load(key); ok && len(entry.Raw) > 0 {
+		return entry.Raw, nil
+	}
+	words, err := c.Backend.TranscribeWords(ctx, audioPath, language)
+	if err != nil {
+		return nil, err
+	}
+	c.store(key, cacheEntry{Raw: words})
+	return words, nil
+}
+
+func (c *CachedAligner) key(kind, audioHash, language, extra string) string {
+	h := sha256.New()
+	for _, part := range []string{kind, audioHash, language, c.Settings, extra} {
+		_, _ = io.WriteString(h, part)
+		_, _ = h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *CachedAligner) path(key string) string {
+	return filepath.Join(c.Dir, key+".json")
+}
+
+func (c *CachedAligner) load(key string) (cacheEntry, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Version != cacheVersion {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// store writes best-effort; a failed write only costs a future re-run.
+func (c *CachedAligner) store(key string, entry cacheEntry) {
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return
+	}
+	entry.Version = cacheVersion
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	tmp := c.path(key) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return
+	}
+	_ = os.Rename(tmp, c.path(key))
+}
+
+// ClearCache removes cached alignment entries from dir and returns how many were deleted.
+func ClearCache(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	removed := 0
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !(strings.HasSuffix(name, ".json") || strings.HasSuffix(name, ".json.tmp")) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func wordTimingsToFlat(words []WordTiming) []flatWord {
+	out := make([]flatWord, 0, len(words))
+	for _, w := range words {
+		out = append(out, flatWord{Word: w.Word, Start: w.Start, End: w.End, Probability: w.Probability})
+	}
+	return out
+}
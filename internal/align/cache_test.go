@@ -0,0 +1,85 @@
+package align
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type countingBackend struct {
+	calls int
+}
+
+func (b *countingBackend) Available() bool { return true }
+
+func (b *countingBackend) transcribe(ctx context.Context, audioPath string, language string, prompt string) ([]flatWord, error) {
+	b.calls++
+	return []flatWord{
+		{Word: "الحمد", Start: 0, End: 500 * time.Millisecond},
+		{Word: "لله", Start: 500 * time.Millisecond, End: 900 * time.Millisecond},
+	}, nil
+}
+
+func (b *countingBackend) Align(ctx context.Context, audioPath string, words []string, language string) ([]WordTiming, error) {
+	raw, _ := b.transcribe(ctx, audioPath, language, "")
+	return alignWords(words, raw)
+}
+
+func (b *countingBackend) TranscribeWords(ctx context.Context, audioPath string, language string) ([]WordTiming, error) {
+	raw, _ := b.transcribe(ctx, audioPath, language, "")
+	return flatToWordTimings(raw), nil
+}
+
+func TestCachedAlignerReusesResults(t *testing.T) {
+	dir := t.TempDir()
+	audioPath := writeTempAudio(t)
+	backend := &countingBackend{}
+	cached := NewCachedAligner(backend, dir, "whisper|base")
+	words := []string{"الْحَمْدُ", "لِلَّهِ"}
+
+	first, err := cached.Align(context.Background(), audioPath, words, "ar")
+	if err != nil {
+		t.Fatalf("Align failed: %v", err)
+	}
+	second, err := cached.Align(context.Background(), audioPath, words, "ar")
+	if err != nil {
+		t.Fatalf("cached Align failed: %v", err)
+	}
+	if backend.calls != 1 {
+		t.Fatalf("expected one transcription, got %d", backend.calls)
+	}
+	if len(second) != len(first) || second[1] != first[1] {
+		t.Fatalf("cached result differs: %+v vs %+v", first, second)
+	}
+	if _, err := cached.TranscribeWords(context.Background(), audioPath, "ar"); err != nil {
+		t.Fatalf("TranscribeWords failed: %v", err)
+	}
+	if _, err := cached.TranscribeWords(context.Background(), audioPath, "ar"); err != nil {
+		t.Fatalf("cached TranscribeWords failed: %v", err)
+	}
+	if backend.calls != 2 {
+		t.Fatalf("expected transcription to be cached, got %d calls", backend.calls)
+	}
+
+	other := NewCachedAligner(backend, dir, "whisper|large")
+	if _, err := other.Align(context.Background(), audioPath, words, "ar"); err != nil {
+		t.Fatalf("Align failed: %v", err)
+	}
+	if backend.calls != 3 {
+		t.Fatalf("expected new settings to miss the cache, got %d calls", backend.calls)
+	}
+
+	removed, err := ClearCache(dir)
+	if err != nil {
+		t.Fatalf("ClearCache failed: %v", err)
+	}
+	if removed == 0 {
+		t.Fatalf("expected cache entries to be removed")
+	}
+	if _, err := cached.Align(context.Background(), audioPath, words, "ar"); err != nil {
+		t.Fatalf("Align failed: %v", err)
+	}
+	if backend.calls != 4 {
+		t.Fatalf("expected cleared cache to re-run, got %d calls", backend.calls)
+	}
+}
@@ -0,0 +1,304 @@
+package align
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"qgencodex/internal/audio"
+	"qgencodex/internal/ffmpeg"
+)
+
+// ChunkedAligner splits long recordings at silences into overlapping chunks
+// and transcribes them concurrently with the wrapped backend. Recordings
+// shorter than one and a half chunks go to the backend unchanged.
+type ChunkedAligner struct {
+	Backend Backend
+	Chunk   time.Duration
+	Overlap time.Duration
+	Workers int
+	// Silences finds split points; nil uses ffmpeg silencedetect.
+	Silences func(ctx context.Context, audioPath string) ([]audio.Silence, error)
+	// Duration returns the recording length in seconds; nil uses ffprobe.
+	Duration func(ctx context.Context, audioPath string) (float64, error)
+	TempDir  string
+
+	mu    sync.Mutex
+	plans map[string][]audioChunk
+}
+
+func NewChunkedAligner(backend Backend, chunk, overlap time.Duration, workers int) *ChunkedAligner {
+	return &ChunkedAligner{Backend: backend, Chunk: chunk, Overlap: overlap, Workers: workers}
+}
+
+// audioChunk is the span cut from the recording (with overlap) and the core
+// range whose words it owns after stitching.
+type audioChunk struct {
+	Start     time.Duration
+	End       time.Duration
+	CoreStart time.Duration
+	CoreEnd   time.Duration
+}
+
+func (c *ChunkedAligner) Available() bool {
+	return c.Backend != nil && c.Backend.Available()
+}
+
+func (c *ChunkedAligner) Align(ctx context.Context, audioPath string, words []string, language string) ([]WordTiming, error) {
+	if len(words) == 0 {
+		return nil, errors.New("no words to align")
+	}
+	chunks := c.plan(ctx, audioPath)
+	if len(chunks) < 2 {
+		return c.Backend.Align(ctx, audioPath, words, language)
+	}
+	raw, err := c.transcribeChunks(ctx, audioPath, language, chunks)
+	if err != nil {
+		return nil, err
+	}
+	return alignWords(words, raw)
+}
+
+func (c *ChunkedAligner) TranscribeWords(ctx context.Context, audioPath string, language string) ([]WordTiming, error) {
+	chunks := c.plan(ctx, audioPath)
+	if len(chunks) < 2 {
+		return c.Backend.TranscribeWords(ctx, audioPath, language)
+	}
+	raw, err := c.transcribeChunks(ctx, audioPath, language, chunks)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, errors.New("no words found in whisper output")
+	}
+	return flatToWordTimings(raw), nil
+}
+
+// transcribe lets the cache store chunked transcriptions. The prompt is only
+// passed on for single-pass runs; chunks don't know which words they hold.
+func (c *ChunkedAligner) transcribe(ctx context.Context, audioPath string, language string, prompt string) ([]flatWord, error) {
+	chunks := c.plan(ctx, audioPath)
+	if len(chunks) < 2 {
+		return c.transcribeWhole(ctx, audioPath, language, prompt)
+	}
+	return c.transcribeChunks(ctx, audioPath, language, chunks)
+}
+
+func (c *ChunkedAligner) transcribeWhole(ctx context.Context, audioPath string, language string, prompt string) ([]flatWord, error) {
+	if t, ok := c.Backend.(transcriber); ok {
+		return t.transcribe(ctx, audioPath, language, prompt)
+	}
+	words, err := c.Backend.TranscribeWords(ctx, audioPath, language)
+	if err != nil {
+		return nil, err
+	}
+	return wordTimingsToFlat(words), nil
+}
+
+// plan returns the chunk layout, or nil when the recording is short or its
+// length is unknown. Each recording is probed once; later calls reuse its
+// layout.
+func (c *ChunkedAligner) plan(ctx context.Context, audioPath string) []audioChunk {
+	if c.Chunk <= 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if chunks, ok := c.plans[audioPath]; ok {
+		return chunks
+	}
+	chunks := c.planRecording(ctx, audioPath)
+	if ctx.Err() == nil {
+		if c.plans == nil {
+			c.plans = map[string][]audioChunk{}
+		}
+		c.plans[audioPath] = chunks
+	}
+	return chunks
+}
+
+func (c *ChunkedAligner) planRecording(ctx context.Context, audioPath string) []audioChunk {
+	probe := c.Duration
+	if probe == nil {
+		probe = ffmpeg.ProbeDuration
+	}
+	seconds, err := probe(ctx, audioPath)
+	if err != nil || seconds <= 0 {
+		return nil
+	}
+	total := time.Duration(seconds * float64(time.Second))
+	if total <= c.Chunk*3/2 {
+		return nil
+	}
+	detect := c.Silences
+	if detect == nil {
+		detect = func(ctx context.Context, path string) ([]audio.Silence, error) {
+			return audio.DetectSilences(ctx, path, 0, 0)
+		}
+	}
+	// Without silences the recording is cut at fixed lengths; the overlap
+	// still recovers words that straddle a cut.
+	silences, _ := detect(ctx, audioPath)
+	return planChunks(total, c.Chunk, c.Overlap, silences)
+}
+
+// planChunks cuts total into pieces of about chunk length, moving each cut to
+// the middle of the nearest silence within a quarter chunk of the target.
+func planChunks(total, chunk, overlap time.Duration, silences []audio.Silence) []audioChunk {
+	if chunk <= 0 || total <= chunk*3/2 {
+		return nil
+	}
+	bounds := []time.Duration{0}
+	pos := time.Duration(0)
+	for total-pos > chunk*3/2 {
+		cut := nearestSilence(silences, pos+chunk, chunk/4, pos+chunk/2)
+		bounds = append(bounds, cut)
+		pos = cut
+	}
+	bounds = append(bounds, total)
+	chunks := make([]audioChunk, 0, len(bounds)-1)
+	for i := 0; i+1 < len(bounds); i++ {
+		start, end := bounds[i]-overlap, bounds[i+1]+overlap
+		if start < 0 {
+			start = 0
+		}
+		if end > total {
+			end = total
+		}
+		chunks = append(chunks, audioChunk{Start: start, End: end, CoreStart: bounds[i], CoreEnd: bounds[i+1]})
+	}
+	return chunks
+}
+
+func nearestSilence(silences []audio.Silence, target, window, min time.Duration) time.Duration {
+	best, bestDist := target, window+1
+	for _, s := range silences {
+		mid := (s.Start + s.End) / 2
+		if mid <= min {
+			continue
+		}
+		dist := mid - target
+		if dist < 0 {
+			dist = -dist
+		}
+		if dist <= window && dist < bestDist {
+			best, bestDist = mid, dist
+		}
+	}
+	return best
+}
+
+// transcribeChunks runs the chunks on a worker pool. The first failure
+// cancels the remaining work.
+func (c *ChunkedAligner) transcribeChunks(parent context.Context, audioPath string, language string, chunks []audioChunk) ([]flatWord, error) {
+	dir, err := os.MkdirTemp(c.TempDir, "quranvideo-chunks-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	workers := c.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(chunks) {
+		workers = len(chunks)
+	}
+	results := make([][]flatWord, len(chunks))
+	jobs := make(chan int)
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				words, err := c.transcribeChunk(ctx, dir, audioPath, language, i, chunks[i])
+				if err != nil {
+					once.Do(func() {
+						firstErr = fmt.Errorf("chunk %d (%s-%s): %w", i+1, chunks[i].Start, chunks[i].End, err)
+						cancel()
+					})
+					continue
+				}
+				results[i] = words
+			}
+		}()
+	}
+send:
+	for i := range chunks {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if err := parent.Err(); err != nil {
+		return nil, err
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return stitchChunks(chunks, results), nil
+}
+
+func (c *ChunkedAligner) transcribeChunk(ctx context.Context, dir, audioPath, language string, index int, chunk audioChunk) ([]flatWord, error) {
+	path := filepath.Join(dir, fmt.Sprintf("chunk%03d.wav", index))
+	if err := ffmpeg.Run(ctx, "-y",
+		"-ss", formatSeconds(chunk.Start),
+		"-t", formatSeconds(chunk.End-chunk.Start),
+		"-i", audioPath,
+		"-ac", "1", "-ar", "16000", "-c:a", "pcm_s16le",
+		path,
+	); err != nil {
+		return nil, fmt.Errorf("cut audio: %w", err)
+	}
+	words, err := c.transcribeWhole(ctx, path, language, "")
+	if err != nil {
+		return nil, err
+	}
+	for i := range words {
+		words[i].Start += chunk.Start
+		words[i].End += chunk.Start
+	}
+	return words, nil
+}
+
+// stitchChunks keeps each word only in the chunk whose core range holds its
+// midpoint, dropping the copy transcribed in the neighbour's overlap.
+func stitchChunks(chunks []audioChunk, results [][]flatWord) []flatWord {
+	var out []flatWord
+	for i, words := range results {
+		last := i == len(chunks)-1
+		for _, w := range words {
+			mid := (w.Start + w.End) / 2
+			if mid < chunks[i].CoreStart && i > 0 {
+				continue
+			}
+			if mid >= chunks[i].CoreEnd && !last {
+				continue
+			}
+			if strings.TrimSpace(w.Word) == "" {
+				continue
+			}
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+func formatSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,85 @@
+package align
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"qgencodex/internal/audio"
+)
+
+func TestPlanChunksSnapsToSilences(t *testing.T) {
+	silences := []audio.Silence{
+		{Start: 55 * time.Second, End: 57 * time.Second},
+		{Start: 118 * time.Second, End: 119 * time.Second},
+	}
+	chunks := planChunks(200*time.Second, time.Minute, 5*time.Second, silences)
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %+v", chunks)
+	}
+	if chunks[0].CoreEnd != 56*time.Second || chunks[1].CoreStart != 56*time.Second {
+		t.Fatalf("expected first cut at the silence midpoint, got %+v", chunks)
+	}
+	if chunks[1].CoreEnd != 118500*time.Millisecond {
+		t.Fatalf("expected second cut at 118.5s, got %v", chunks[1].CoreEnd)
+	}
+	if chunks[0].Start != 0 || chunks[1].Start != 51*time.Second || chunks[2].End != 200*time.Second {
+		t.Fatalf("unexpected overlap spans: %+v", chunks)
+	}
+	if planChunks(80*time.Second, time.Minute, 5*time.Second, nil) != nil {
+		t.Fatalf("expected no chunking for short audio")
+	}
+}
+
+func TestStitchChunksDropsOverlap(t *testing.T) {
+	chunks := []audioChunk{
+		{Start: 0, End: 15 * time.Second, CoreStart: 0, CoreEnd: 10 * time.Second},
+		{Start: 5 * time.Second, End: 20 * time.Second, CoreStart: 10 * time.Second, CoreEnd: 20 * time.Second},
+	}
+	results := [][]flatWord{
+		{
+			{Word: "قل", Start: 8 * time.Second, End: 9 * time.Second},
+			{Word: "هو", Start: 9500 * time.Millisecond, End: 10200 * time.Millisecond},
+			{Word: "الله", Start: 11 * time.Second, End: 12 * time.Second},
+		},
+		{
+			{Word: "قل", Start: 8100 * time.Millisecond, End: 9 * time.Second},
+			{Word: "هو", Start: 9600 * time.Millisecond, End: 10200 * time.Millisecond},
+			{Word: "الله", Start: 11 * time.Second, End: 12 * time.Second},
+			{Word: "أحد", Start: 19 * time.Second, End: 21 * time.Second},
+		},
+	}
+	words := stitchChunks(chunks, results)
+	got := make([]string, len(words))
+	for i, w := range words {
+		got[i] = w.Word
+	}
+	if len(words) != 4 || got[0] != "قل" || got[1] != "هو" || got[2] != "الله" || got[3] != "أحد" {
+		t.Fatalf("unexpected stitched words: %v", got)
+	}
+	if words[1].Start != 9500*time.Millisecond || words[2].Start != 11*time.Second {
+		t.Fatalf("expected overlap words from the owning chunk, got %+v", words)
+	}
+}
+
+func TestChunkedAlignerPlansOnce(t *testing.T) {
+	probes, detections := 0, 0
+	c := NewChunkedAligner(nil, time.Minute, 5*time.Second, 2)
+	c.Duration = func(ctx context.Context, audioPath string) (float64, error) {
+		probes++
+		return 200, nil
+	}
+	c.Silences = func(ctx context.Context, audioPath string) ([]audio.Silence, error) {
+		detections++
+		return nil, nil
+	}
+	for i := 0; i < 3; i++ {
+		if chunks := c.plan(context.Background(), "recitation.mp3"); len(chunks) != 3 {
+			t.Fatalf("expected 3 chunks, got %+v", chunks)
+		}
+	}
+	c.plan(context.Background(), "other.mp3")
+	if probes != 2 || detections != 2 {
+		t.Fatalf("expected one probe and silence pass per recording, got %d and %d", probes, detections)
+	}
+}
@@ -0,0 +1,181 @@
+package align
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"qgencodex/internal/retry"
+	"qgencodex/internal/utils"
+)
+
+// HTTPAligner uses an OpenAI-compatible /v1/audio/transcriptions endpoint
+// (OpenAI, faster-whisper-server, ...) with word timestamps.
+type HTTPAligner struct {
+	BaseURL string
+	Model   string
+	APIKey  string
+	Timeout time.Duration
+	// Retries is how many times a failed request is sent again.
+	Retries int
+	// Options supplies the prompt strategy; the server picks its own decoding.
+	Options WhisperOptions
+}
+
+func NewHTTPAligner(baseURL, model, apiKey string, timeout time.Duration, retries int) *HTTPAligner {
+	if model == "" {
+		model = "whisper-1"
+	}
+	return &HTTPAligner{BaseURL: baseURL, Model: model, APIKey: apiKey, Timeout: timeout, Retries: retries}
+}
+
+func (h *HTTPAligner) Available() bool {
+	return strings.TrimSpace(h.BaseURL) != ""
+}
+
+func (h *HTTPAligner) Align(ctx context.Context, audioPath string, words []string, language string) ([]WordTiming, error) {
+	if len(words) == 0 {
+		return nil, errors.New("no words to align")
+	}
+	prompt := strings.TrimSpace(strings.Join(words, " "))
+	whisperWords, err := h.transcribe(ctx, audioPath, language, prompt)
+	if err != nil {
+		return nil, err
+	}
+	return alignWords(words, whisperWords)
+}
+
+func (h *HTTPAligner) TranscribeWords(ctx context.Context, audioPath string, language string) ([]WordTiming, error) {
+	whisperWords, err := h.transcribe(ctx, audioPath, language, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(whisperWords) == 0 {
+		return nil, errors.New("no words found in transcription response")
+	}
+	return flatToWordTimings(whisperWords), nil
+}
+
+// endpoint accepts a server root, a /v1 root or the full transcription URL.
+func (h *HTTPAligner) endpoint() string {
+	base := strings.TrimRight(strings.TrimSpace(h.BaseURL), "/")
+	switch {
+	case strings.HasSuffix(base, "/audio/transcriptions"):
+		return base
+	case strings.HasSuffix(base, "/v1"):
+		return base + "/audio/transcriptions"
+	default:
+		return base + "/v1/audio/transcriptions"
+	}
+}
+
+func (h *HTTPAligner) transcribe(ctx context.Context, audioPath string, language string, prompt string) ([]flatWord, error) {
+	if !h.Available() {
+		return nil, errors.New("transcription base url is not configured")
+	}
+	if language == "" {
+		language = "ar"
+	}
+	audio, err := os.ReadFile(audioPath)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]string{
+		"model":                     h.Model,
+		"language":                  language,
+		"response_format":           "verbose_json",
+		"timestamp_granularities[]": "word",
+		"temperature":               "0",
+	}
+	if prompt = h.Options.InitialPrompt(prompt); prompt != "" {
+		fields["prompt"] = prompt
+	}
+	body, contentType, err := buildTranscriptionForm(filepath.Base(audioPath), audio, fields)
+	if err != nil {
+		return nil, err
+	}
+	client := utils.HTTPClient(h.Timeout)
+	attempts := 1
+	if h.Retries > 0 {
+		attempts += h.Retries
+	}
+	var result httpTranscription
+	err = retry.Do(ctx, attempts, time.Second, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.endpoint(), bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", contentType)
+		if h.APIKey != "" {
+			req.Header.Set("Authorization", "Bearer "+h.APIKey)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			msg, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+			err := fmt.Errorf("transcription http %d: %s", resp.StatusCode, string(bytes.TrimSpace(msg)))
+			// Only rate limits and server errors are worth another attempt.
+			if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+				return retry.Permanent(err)
+			}
+			return err
+		}
+		result = httpTranscription{}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return retry.Permanent(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.flatWords(), nil
+}
+
+func buildTranscriptionForm(filename string, audio []byte, fields map[string]string) ([]byte, string, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for k, v := range fields {
+		if err := mw.WriteField(k, v); err != nil {
+			return nil, "", err
+		}
+	}
+	part, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := part.Write(audio); err != nil {
+		return nil, "", err
+	}
+	if err := mw.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), mw.FormDataContentType(), nil
+}
+
+// httpTranscription is the verbose_json response. Word timestamps are in the
+// top-level words array; some servers only nest them in segments.
+type httpTranscription struct {
+	Text     string           `json:"text"`
+	Words    []whisperWord    `json:"words"`
+	Segments []whisperSegment `json:"segments"`
+}
+
+func (r httpTranscription) flatWords() []flatWord {
+	if len(r.Words) == 0 {
+		return flattenWhisperWords(whisperResult{Segments: r.Segments})
+	}
+	return flattenWhisperWords(whisperResult{Segments: []whisperSegment{{Words: r.Words}}})
+}
@@ -0,0 +1,133 @@
+package align
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func writeTempAudio(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ayah.mp3")
+	if err := os.WriteFile(path, []byte("fake-audio"), 0o644); err != nil {
+		t.Fatalf("write audio: %v", err)
+	}
+	return path
+}
+
+func TestHTTPAlignerAlign(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audio/transcriptions" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer KEY" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.FormValue("model") != "small" || r.FormValue("timestamp_granularities[]") != "word" || r.FormValue("response_format") != "verbose_json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if _, _, err := r.FormFile("file"); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"text": "قل هو الله احد",
+			"words": []map[string]any{
+				{"word": " قل", "start": 0.0, "end": 0.4},
+				{"word": " هو", "start": 0.4, "end": 0.7},
+				{"word": " الله", "start": 0.7, "end": 1.2},
+				{"word": " احد", "start": 1.2, "end": 2.0},
+			},
+		})
+	}))
+	defer server.Close()
+
+	aligner := NewHTTPAligner(server.URL, "small", "KEY", 2*time.Second, 1)
+	words := []string{"قُلْ", "هُوَ", "اللَّهُ", "أَحَدٌ"}
+	aligned, err := aligner.Align(context.Background(), writeTempAudio(t), words, "ar")
+	if err != nil {
+		t.Fatalf("Align failed: %v", err)
+	}
+	if len(aligned) != 4 {
+		t.Fatalf("expected 4 timings, got %d", len(aligned))
+	}
+	if aligned[2].Start != 700*time.Millisecond || aligned[3].End != 2*time.Second {
+		t.Fatalf("unexpected timings: %+v", aligned)
+	}
+}
+
+func TestHTTPAlignerRetriesAndReadsSegmentWords(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"segments": []map[string]any{
+				{"words": []map[string]any{{"word": " الحمد", "start": 0.0, "end": 0.5}, {"word": " لله", "start": 0.5, "end": 0.9}}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	aligner := NewHTTPAligner(server.URL+"/v1", "", "", 2*time.Second, 2)
+	words, err := aligner.TranscribeWords(context.Background(), writeTempAudio(t), "ar")
+	if err != nil {
+		t.Fatalf("TranscribeWords failed: %v", err)
+	}
+	if atomic.LoadInt32(&calls) != 2 {
+		t.Fatalf("expected one retry, got %d calls", calls)
+	}
+	if len(words) != 2 || words[1].Word != "لله" || words[1].End != 900*time.Millisecond {
+		t.Fatalf("unexpected words: %+v", words)
+	}
+}
+
+func TestHTTPAlignerDoesNotRetryClientErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	aligner := NewHTTPAligner(server.URL, "", "", 2*time.Second, 3)
+	if _, err := aligner.TranscribeWords(context.Background(), writeTempAudio(t), "ar"); err == nil {
+		t.Fatalf("expected an error")
+	}
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("expected no retry on 401, got %d calls", calls)
+	}
+}
+
+func TestHTTPAlignerRetriesCount(t *testing.T) {
+	for retries, want := range map[int]int32{0: 1, 1: 2} {
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		aligner := NewHTTPAligner(server.URL, "", "", 2*time.Second, retries)
+		if _, err := aligner.TranscribeWords(context.Background(), writeTempAudio(t), "ar"); err == nil {
+			t.Fatalf("expected an error")
+		}
+		server.Close()
+		if got := atomic.LoadInt32(&calls); got != want {
+			t.Fatalf("expected %d requests with %d retries, got %d", want, retries, got)
+		}
+	}
+}
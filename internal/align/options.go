@@ -0,0 +1,137 @@
+package align
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// defaultBeamSize is used when WhisperOptions.BeamSize is zero.
+const defaultBeamSize = 5
+
+// WhisperOptions are the model and decoding settings shared by the Whisper
+// backends and the recognizer.
+type WhisperOptions struct {
+	Model    string
+	Device   string
+	Threads  int
+	BeamSize int
+	// Prompt selects the initial prompt: "full" (default), "first:N" or "none".
+	Prompt    string
+	VADFilter bool
+	ExtraArgs []string
+}
+
+// InitialPrompt applies the prompt strategy to the expected text.
+func (o WhisperOptions) InitialPrompt(text string) string {
+	text = strings.TrimSpace(text)
+	strategy := strings.ToLower(strings.TrimSpace(o.Prompt))
+	switch {
+	case strategy == "" || strategy == "full":
+		return text
+	case strategy == "none":
+		return ""
+	case strings.HasPrefix(strategy, "first:"):
+		n, err := strconv.Atoi(strings.TrimPrefix(strategy, "first:"))
+		if err != nil || n <= 0 {
+			return text
+		}
+		words := strings.Fields(text)
+		if len(words) > n {
+			words = words[:n]
+		}
+		return strings.Join(words, " ")
+	default:
+		return text
+	}
+}
+
+// ModelArgs returns the whisper CLI flags that select the model and runtime.
+// They are kept when the decoding flags are dropped on retry.
+func (o WhisperOptions) ModelArgs() []string {
+	var args []string
+	if o.Model != "" {
+		args = append(args, "--model", o.Model)
+	}
+	if o.Device != "" {
+		args = append(args, "--device", o.Device)
+	}
+	if o.Threads > 0 {
+		args = append(args, "--threads", strconv.Itoa(o.Threads))
+	}
+	return append(args, o.ExtraArgs...)
+}
+
+// DecodingArgs returns the decoding flags of the whisper CLI cmd for prompt,
+// which is reduced by the prompt strategy first. --vad_filter is only passed
+// to CLIs that accept it.
+func (o WhisperOptions) DecodingArgs(cmd, prompt string) []string {
+	beam := strconv.Itoa(o.beamSize())
+	args := []string{
+		"--temperature", "0",
+		"--beam_size", beam,
+		"--best_of", beam,
+	}
+	if o.VADFilter && SupportsVAD(cmd) {
+		args = append(args, "--vad_filter", "True")
+	}
+	if prompt = o.InitialPrompt(prompt); prompt != "" {
+		args = append(args, "--initial_prompt", prompt)
+	}
+	return args
+}
+
+// SupportsVAD reports whether the whisper CLI cmd accepts --vad_filter, as
+// faster-whisper's whisper-ctranslate2 does; openai-whisper does not.
+func SupportsVAD(cmd string) bool {
+	name := strings.ToLower(filepath.Base(cmd))
+	return strings.Contains(name, "ctranslate2") || strings.Contains(name, "faster")
+}
+
+// cppArgs maps the options onto whisper-cli flags. The model path comes from
+// audio.whisper_cpp_model, and "cpu" disables the GPU.
+func (o WhisperOptions) cppArgs(prompt string) []string {
+	args := []string{"-bs", strconv.Itoa(o.beamSize())}
+	if o.Threads > 0 {
+		args = append(args, "-t", strconv.Itoa(o.Threads))
+	}
+	if strings.EqualFold(o.Device, "cpu") {
+		args = append(args, "-ng")
+	}
+	if prompt = o.InitialPrompt(prompt); prompt != "" {
+		args = append(args, "--prompt", prompt)
+	}
+	return append(args, o.ExtraArgs...)
+}
+
+// Fingerprint identifies the settings that change transcription output.
+func (o WhisperOptions) Fingerprint() string {
+	return strings.Join([]string{
+		o.Model,
+		o.Device,
+		strconv.Itoa(o.beamSize()),
+		strings.ToLower(o.Prompt),
+		strconv.FormatBool(o.VADFilter),
+		strings.Join(o.ExtraArgs, " "),
+	}, "|")
+}
+
+func (o WhisperOptions) beamSize() int {
+	if o.BeamSize > 0 {
+		return o.BeamSize
+	}
+	return defaultBeamSize
+}
+
+// CommandLine formats a command for logs, quoting arguments with spaces.
+func CommandLine(cmd string, args []string) string {
+	parts := make([]string, 0, len(args)+1)
+	for _, arg := range append([]string{cmd}, args...) {
+		if arg == "" || strings.ContainsAny(arg, " \t\"'") {
+			arg = fmt.Sprintf("%q", arg)
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
@@ -1,6 +1,7 @@
 package align
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,7 +14,12 @@ import (
 )
 
 type WhisperAligner struct {
-	Cmd string
+	Cmd     string
+	Options WhisperOptions
+	// Logf, when set, receives the effective command line of each run.
+	Logf func(format string, args ...any)
+	// Warnf, when set, is told when options are ignored or dropped.
+	Warnf func(format string, args ...any)
 }
 
 func NewWhisperAligner(cmd string) *WhisperAligner {
@@ -28,57 +34,21 @@ func (w *WhisperAligner) Available() bool {
 	return err == nil
 }
 
-func (w *WhisperAligner) Align(audioPath string, words []string, language string) ([]WordTiming, error) {
-	if !w.Available() {
-		return nil, fmt.Errorf("whisper command not found: %s", w.Cmd)
-	}
+func (w *WhisperAligner) Align(ctx context.Context, audioPath string, words []string, language string) ([]WordTiming, error) {
 	if len(words) == 0 {
 		return nil, errors.New("no words to align")
 	}
-	if language == "" {
-		language = "ar"
-	}
-	outputDir, err := os.MkdirTemp("", "quranvideo-whisper-*")
-	if err != nil {
-		return nil, err
-	}
-	defer os.RemoveAll(outputDir)
-
-	jsonPath := filepath.Join(outputDir, strings.TrimSuffix(filepath.Base(audioPath), filepath.Ext(audioPath))+".json")
 	prompt := strings.TrimSpace(strings.Join(words, " "))
-	baseArgs := []string{
-		"--language", language,
-		"--word_timestamps", "True",
-		"--output_format", "json",
-		"--output_dir", outputDir,
-		"--task", "transcribe",
-		audioPath,
-	}
-	advancedArgs := append([]string{}, baseArgs...)
-	advancedArgs = append(advancedArgs,
-		"--temperature", "0",
-		"--beam_size", "5",
-		"--best_of", "5",
-	)
-	if prompt != "" {
-		advancedArgs = append(advancedArgs, "--initial_prompt", prompt)
-	}
-	if err := runWhisper(w.Cmd, advancedArgs); err != nil {
-		_ = os.Remove(jsonPath)
-		if err := runWhisper(w.Cmd, baseArgs); err != nil {
-			return nil, err
-		}
-	}
-
-	data, err := os.ReadFile(jsonPath)
+	whisperWords, err := w.transcribe(ctx, audioPath, language, prompt)
 	if err != nil {
 		return nil, err
 	}
-	var result whisperResult
-	if err := json.Unmarshal(data, &result); err != nil {
-		return nil, err
-	}
-	whisperWords := flattenWhisperWords(result)
+	return alignWords(words, whisperWords)
+}
+
+// alignWords maps the expected words onto transcribed words, trying the
+// strategies from most to least precise.
+func alignWords(words []string, whisperWords []flatWord) ([]WordTiming, error) {
 	if len(whisperWords) == 0 {
 		return nil, errors.New("no words found in whisper output")
 	}
@@ -106,7 +76,20 @@ func (w *WhisperAligner) Align(audioPath string, words []string, language string
 	return nil, fmt.Errorf("unable to align %d words with %d whisper words", len(words), len(whisperWords))
 }
 
-func (w *WhisperAligner) TranscribeWords(audioPath string, language string) ([]WordTiming, error) {
+func (w *WhisperAligner) TranscribeWords(ctx context.Context, audioPath string, language string) ([]WordTiming, error) {
+	whisperWords, err := w.transcribe(ctx, audioPath, language, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(whisperWords) == 0 {
+		return nil, errors.New("no words found in whisper output")
+	}
+	return flatToWordTimings(whisperWords), nil
+}
+
+// transcribe runs the whisper CLI, retrying without decoding options if the
+// installed version rejects them.
+func (w *WhisperAligner) transcribe(ctx context.Context, audioPath string, language string, prompt string) ([]flatWord, error) {
 	if !w.Available() {
 		return nil, fmt.Errorf("whisper command not found: %s", w.Cmd)
 	}
@@ -126,17 +109,23 @@ func (w *WhisperAligner) TranscribeWords(audioPath string, language string) ([]W
 		"--output_format", "json",
 		"--output_dir", outputDir,
 		"--task", "transcribe",
-		audioPath,
 	}
+	baseArgs = append(baseArgs, w.Options.ModelArgs()...)
+	baseArgs = append(baseArgs, audioPath)
 	advancedArgs := append([]string{}, baseArgs...)
-	advancedArgs = append(advancedArgs,
-		"--temperature", "0",
-		"--beam_size", "5",
-		"--best_of", "5",
-	)
-	if err := runWhisper(w.Cmd, advancedArgs); err != nil {
+	advancedArgs = append(advancedArgs, w.Options.DecodingArgs(w.Cmd, prompt)...)
+	if w.Options.VADFilter && !SupportsVAD(w.Cmd) && w.Warnf != nil {
+		w.Warnf("%s does not support --vad_filter; ignoring whisper.vad_filter", w.Cmd)
+	}
+	if err := runWhisper(ctx, w.Logf, w.Cmd, advancedArgs); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if w.Warnf != nil {
+			w.Warnf("%s failed with decoding options: %v; retrying without beam size, prompt and VAD", w.Cmd, err)
+		}
 		_ = os.Remove(jsonPath)
-		if err := runWhisper(w.Cmd, baseArgs); err != nil {
+		if err := runWhisper(ctx, w.Logf, w.Cmd, baseArgs); err != nil {
 			return nil, err
 		}
 	}
@@ -149,15 +138,15 @@ func (w *WhisperAligner) TranscribeWords(audioPath string, language string) ([]W
 	if err := json.Unmarshal(data, &result); err != nil {
 		return nil, err
 	}
-	whisperWords := flattenWhisperWords(result)
-	if len(whisperWords) == 0 {
-		return nil, errors.New("no words found in whisper output")
-	}
-	out := make([]WordTiming, 0, len(whisperWords))
-	for _, w := range whisperWords {
-		out = append(out, WordTiming{Word: w.Word, Start: w.Start, End: w.End})
+	return flattenWhisperWords(result), nil
+}
+
+func flatToWordTimings(words []flatWord) []WordTiming {
+	out := make([]WordTiming, 0, len(words))
+	for _, w := range words {
+		out = append(out, WordTiming{Word: w.Word, Start: w.Start, End: w.End, Probability: w.Probability})
 	}
-	return out, nil
+	return out
 }
 
 type whisperResult struct {
@@ -169,15 +158,17 @@ type whisperSegment struct {
 }
 
 type whisperWord struct {
-	Word  string  `json:"word"`
-	Start float64 `json:"start"`
-	End   float64 `json:"end"`
+	Word        string  `json:"word"`
+	Start       float64 `json:"start"`
+	End         float64 `json:"end"`
+	Probability float64 `json:"probability"`
 }
 
 type flatWord struct {
-	Word  string
-	Start time.Duration
-	End   time.Duration
+	Word        string
+	Start       time.Duration
+	End         time.Duration
+	Probability float64
 }
 
 func flattenWhisperWords(result whisperResult) []flatWord {
@@ -185,9 +176,10 @@ func flattenWhisperWords(result whisperResult) []flatWord {
 	for _, seg := range result.Segments {
 		for _, w := range seg.Words {
 			out = append(out, flatWord{
-				Word:  strings.TrimSpace(w.Word),
-				Start: time.Duration(w.Start * float64(time.Second)),
-				End:   time.Duration(w.End * float64(time.Second)),
+				Word:        strings.TrimSpace(w.Word),
+				Start:       time.Duration(w.Start * float64(time.Second)),
+				End:         time.Duration(w.End * float64(time.Second)),
+				Probability: w.Probability,
 			})
 		}
 	}
@@ -200,9 +192,11 @@ type alignUnit struct {
 }
 
 type unitTiming struct {
-	Start   time.Duration
-	End     time.Duration
-	Matched bool
+	Start        time.Duration
+	End          time.Duration
+	Matched      bool
+	Interpolated bool
+	Probability  float64
 }
 
 func buildAlignUnits(words []string) []alignUnit {
@@ -254,9 +248,10 @@ func alignUnitsToWhisper(units []alignUnit, whisperWords []flatWord) ([]unitTimi
 	timings := make([]unitTiming, len(units))
 	for _, m := range matches {
 		timings[m.i] = unitTiming{
-			Start:   whisperWords[m.j].Start,
-			End:     whisperWords[m.j].End,
-			Matched: true,
+			Start:       whisperWords[m.j].Start,
+			End:         whisperWords[m.j].End,
+			Matched:     true,
+			Probability: whisperWords[m.j].Probability,
 		}
 	}
 	fillMissingUnitTimings(timings, whisperWords)
@@ -349,7 +344,7 @@ func fillUnitGap(timings []unitTiming, prevIdx, nextIdx int, firstStart, lastEnd
 		if step == 0 {
 			e = s
 		}
-		timings[i] = unitTiming{Start: s, End: e, Matched: true}
+		timings[i] = unitTiming{Start: s, End: e, Matched: true, Interpolated: true}
 		cursor = e
 	}
 }
@@ -368,11 +363,17 @@ func expandUnitsToWords(units []alignUnit, unitTimings []unitTiming, words []str
 		if ut.End < ut.Start {
 			ut.End = ut.Start
 		}
+		source := SourceMatched
+		if ut.Interpolated {
+			source = SourceInterpolated
+		}
 		if len(unit.Indices) == 1 {
 			wordIdx := unit.Indices[0]
 			if wordIdx >= 0 && wordIdx < len(result) {
 				result[wordIdx].Start = ut.Start
 				result[wordIdx].End = ut.End
+				result[wordIdx].Source = source
+				result[wordIdx].Probability = ut.Probability
 				assigned[wordIdx] = true
 			}
 			continue
@@ -411,6 +412,8 @@ func expandUnitsToWords(units []alignUnit, unitTimings []unitTiming, words []str
 			if wordIdx >= 0 && wordIdx < len(result) {
 				result[wordIdx].Start = start
 				result[wordIdx].End = end
+				result[wordIdx].Source = source
+				result[wordIdx].Probability = ut.Probability
 				assigned[wordIdx] = true
 			}
 			cursor = end
@@ -491,6 +494,7 @@ func fillWordGap(timings []WordTiming, assigned []bool, prevIdx, nextIdx int, fi
 		}
 		timings[i].Start = s
 		timings[i].End = e
+		timings[i].Source = SourceInterpolated
 		if assigned != nil && len(assigned) == len(timings) {
 			assigned[i] = true
 		}
@@ -540,7 +544,7 @@ func alignByNormalizedMatch(words []string, whisperWords []flatWord) ([]WordTimi
 		for idx < len(whisperWords) {
 			candidate := normalizeWord(whisperWords[idx].Word)
 			if needle != "" && needle == candidate {
-				aligned = append(aligned, WordTiming{Word: w, Start: whisperWords[idx].Start, End: whisperWords[idx].End})
+				aligned = append(aligned, matchedTiming(w, whisperWords[idx]))
 				idx++
 				found = true
 				break
@@ -613,7 +617,7 @@ func alignByLCS(words []string, whisperWords []flatWord) ([]WordTiming, bool) {
 		timings[i].Word = words[i]
 	}
 	for _, p := range matches {
-		timings[p.i] = WordTiming{Word: words[p.i], Start: whisperWords[p.j].Start, End: whisperWords[p.j].End}
+		timings[p.i] = matchedTiming(words[p.i], whisperWords[p.j])
 		matched[p.i] = true
 	}
 	fillMissingTimings(timings, matched, whisperWords)
@@ -638,12 +642,19 @@ func alignByIndexStrict(words []string, whisperWords []flatWord) ([]WordTiming,
 	}
 	aligned := make([]WordTiming, 0, len(words))
 	for i, w := range words {
-		ww := whisperWords[i]
-		aligned = append(aligned, WordTiming{Word: w, Start: ww.Start, End: ww.End})
+		wt := matchedTiming(w, whisperWords[i])
+		if normalizeWord(w) != normalizeWord(whisperWords[i].Word) {
+			wt.Source = SourceIndex
+		}
+		aligned = append(aligned, wt)
 	}
 	return aligned, true
 }
 
+func matchedTiming(word string, ww flatWord) WordTiming {
+	return WordTiming{Word: word, Start: ww.Start, End: ww.End, Source: SourceMatched, Probability: ww.Probability}
+}
+
 func fillMissingTimings(timings []WordTiming, matched []bool, whisperWords []flatWord) {
 	if len(timings) == 0 || len(whisperWords) == 0 {
 		return
@@ -692,7 +703,7 @@ func fillGap(timings []WordTiming, matched []bool, prevIdx, nextIdx int, firstSt
 		if step == 0 {
 			e = s
 		}
-		timings[i] = WordTiming{Word: timings[i].Word, Start: s, End: e}
+		timings[i] = WordTiming{Word: timings[i].Word, Start: s, End: e, Source: SourceInterpolated}
 		matched[i] = true
 		cursor = e
 	}
@@ -716,9 +727,13 @@ func NormalizeWord(word string) string {
 	return normalizeWord(word)
 }
 
-func runWhisper(cmd string, args []string) error {
-	c := exec.Command(cmd, args...)
-	c.Stdout = os.Stdout
+func runWhisper(ctx context.Context, logf func(format string, args ...any), cmd string, args []string) error {
+	if logf != nil {
+		logf("Running %s", CommandLine(cmd, args))
+	}
+	c := exec.CommandContext(ctx, cmd, args...)
+	// Progress goes to stderr so it never mixes with the command's own output.
+	c.Stdout = os.Stderr
 	c.Stderr = os.Stderr
 	return c.Run()
 }
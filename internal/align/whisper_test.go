@@ -0,0 +1,66 @@
+package align
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAlignWordsRecordsProvenance(t *testing.T) {
+	whisperWords := []flatWord{
+		{Word: "قل", Start: 0, End: 400 * time.Millisecond, Probability: 0.9},
+		{Word: "هو", Start: 400 * time.Millisecond, End: 700 * time.Millisecond, Probability: 0.8},
+		{Word: "xx", Start: 700 * time.Millisecond, End: 1200 * time.Millisecond, Probability: 0.3},
+		{Word: "احد", Start: 1200 * time.Millisecond, End: 2000 * time.Millisecond, Probability: 0.95},
+	}
+	words := []string{"قُلْ", "هُوَ", "اللَّهُ", "أَحَدٌ"}
+	aligned, err := alignWords(words, whisperWords)
+	if err != nil {
+		t.Fatalf("align failed: %v", err)
+	}
+	want := []Source{SourceMatched, SourceMatched, SourceInterpolated, SourceInterpolated}
+	// "أحد" does not normalize to "احد" (hamza), so it is interpolated too.
+	for i, wt := range aligned {
+		if wt.Source != want[i] {
+			t.Fatalf("word %d: expected %s, got %s", i, want[i], wt.Source)
+		}
+	}
+	if aligned[0].Probability != 0.9 || aligned[2].Confidence() != 0 {
+		t.Fatalf("unexpected confidence data: %+v", aligned)
+	}
+	if score := Score(aligned); score < 0.42 || score > 0.43 {
+		t.Fatalf("expected mean confidence 0.425, got %v", score)
+	}
+}
+
+func TestAlignByIndexStrictMarksIndexWords(t *testing.T) {
+	whisperWords := []flatWord{
+		{Word: "الحمد", End: time.Second},
+		{Word: "لله", Start: time.Second, End: 2 * time.Second},
+		{Word: "رب", Start: 2 * time.Second, End: 3 * time.Second},
+		{Word: "علمين", Start: 3 * time.Second, End: 4 * time.Second},
+	}
+	aligned, ok := alignByIndexStrict([]string{"الحمد", "لله", "رب", "العالمين"}, whisperWords)
+	if !ok {
+		t.Fatalf("expected index alignment")
+	}
+	if aligned[2].Source != SourceMatched || aligned[3].Source != SourceIndex {
+		t.Fatalf("unexpected sources: %+v", aligned)
+	}
+}
+
+func TestWhisperOptionsArgs(t *testing.T) {
+	opts := WhisperOptions{Model: "large-v3", Threads: 4, Prompt: "first:2", VADFilter: true, ExtraArgs: []string{"--fp16", "False"}}
+	got := CommandLine("whisper-ctranslate2", append(opts.ModelArgs(), opts.DecodingArgs("whisper-ctranslate2", "قل هو الله أحد")...))
+	want := `whisper-ctranslate2 --model large-v3 --threads 4 --fp16 False --temperature 0 --beam_size 5 --best_of 5 --vad_filter True --initial_prompt "قل هو"`
+	if got != want {
+		t.Fatalf("unexpected command line:\n got %s\nwant %s", got, want)
+	}
+	// openai-whisper has no --vad_filter flag.
+	if got := CommandLine("whisper", opts.DecodingArgs("/usr/local/bin/whisper", "")); strings.Contains(got, "vad_filter") {
+		t.Fatalf("expected no VAD flag for openai-whisper, got %s", got)
+	}
+	if p := (WhisperOptions{Prompt: "none"}).InitialPrompt("قل هو"); p != "" {
+		t.Fatalf("expected no prompt, got %q", p)
+	}
+}
@@ -0,0 +1,191 @@
+package align
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"qgencodex/internal/ffmpeg"
+)
+
+// WhisperCppAligner runs whisper.cpp (whisper-cli) instead of the Python whisper CLI.
+type WhisperCppAligner struct {
+	Cmd     string
+	Model   string
+	Options WhisperOptions
+	// Logf, when set, receives the effective command line of each run.
+	Logf func(format string, args ...any)
+}
+
+func NewWhisperCppAligner(cmd string, model string) *WhisperCppAligner {
+	if cmd == "" {
+		cmd = "whisper-cli"
+	}
+	return &WhisperCppAligner{Cmd: cmd, Model: model}
+}
+
+func (w *WhisperCppAligner) Available() bool {
+	if _, err := exec.LookPath(w.Cmd); err != nil {
+		return false
+	}
+	if w.Model == "" {
+		return false
+	}
+	info, err := os.Stat(w.Model)
+	return err == nil && !info.IsDir()
+}
+
+func (w *WhisperCppAligner) Align(ctx context.Context, audioPath string, words []string, language string) ([]WordTiming, error) {
+	if len(words) == 0 {
+		return nil, errors.New("no words to align")
+	}
+	prompt := strings.TrimSpace(strings.Join(words, " "))
+	whisperWords, err := w.transcribe(ctx, audioPath, language, prompt)
+	if err != nil {
+		return nil, err
+	}
+	return alignWords(words, whisperWords)
+}
+
+func (w *WhisperCppAligner) TranscribeWords(ctx context.Context, audioPath string, language string) ([]WordTiming, error) {
+	whisperWords, err := w.transcribe(ctx, audioPath, language, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(whisperWords) == 0 {
+		return nil, errors.New("no words found in whisper.cpp output")
+	}
+	return flatToWordTimings(whisperWords), nil
+}
+
+func (w *WhisperCppAligner) transcribe(ctx context.Context, audioPath string, language string, prompt string) ([]flatWord, error) {
+	if !w.Available() {
+		return nil, fmt.Errorf("whisper.cpp not available: %s (model %q)", w.Cmd, w.Model)
+	}
+	if language == "" {
+		language = "ar"
+	}
+	outputDir, err := os.MkdirTemp("", "quranvideo-whispercpp-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(outputDir)
+
+	// whisper.cpp only reads 16 kHz mono WAV.
+	wavPath := filepath.Join(outputDir, "input.wav")
+	if err := ffmpeg.Run(ctx, "-y", "-i", audioPath, "-ar", "16000", "-ac", "1", "-c:a", "pcm_s16le", wavPath); err != nil {
+		return nil, fmt.Errorf("convert audio for whisper.cpp: %w", err)
+	}
+	outBase := filepath.Join(outputDir, "output")
+	args := []string{
+		"-m", w.Model,
+		"-f", wavPath,
+		"-l", language,
+		"-ml", "1",
+		"-sow",
+		"-ojf",
+		"-of", outBase,
+	}
+	args = append(args, w.Options.cppArgs(prompt)...)
+	if err := runWhisper(ctx, w.Logf, w.Cmd, args); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(outBase + ".json")
+	if err != nil {
+		return nil, err
+	}
+	return parseWhisperCppJSON(data)
+}
+
+type whisperCppResult struct {
+	Transcription []whisperCppSegment `json:"transcription"`
+}
+
+type whisperCppSegment struct {
+	Text    string            `json:"text"`
+	Offsets whisperCppOffsets `json:"offsets"`
+	Tokens  []whisperCppToken `json:"tokens"`
+}
+
+type whisperCppToken struct {
+	Text    string            `json:"text"`
+	Offsets whisperCppOffsets `json:"offsets"`
+	P       float64           `json:"p"`
+}
+
+// whisperCppOffsets are in milliseconds.
+type whisperCppOffsets struct {
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+}
+
+// parseWhisperCppJSON reads whisper.cpp output (-oj or -ojf). Token-level
+// timestamps from -ojf are preferred; otherwise each segment is used, which
+// with -ml 1 is one word (or word piece) per segment. Pieces without a
+// leading space are merged into the preceding word.
+func parseWhisperCppJSON(data []byte) ([]flatWord, error) {
+	var result whisperCppResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	var pieces []whisperCppToken
+	for _, seg := range result.Transcription {
+		if len(seg.Tokens) == 0 {
+			pieces = append(pieces, whisperCppToken{Text: seg.Text, Offsets: seg.Offsets})
+			continue
+		}
+		first := true
+		for _, tok := range seg.Tokens {
+			if isWhisperCppSpecial(tok.Text) {
+				continue
+			}
+			if first && !strings.HasPrefix(tok.Text, " ") && strings.HasPrefix(seg.Text, " ") {
+				// The segment text carries the word break the first token lacks.
+				tok.Text = " " + tok.Text
+			}
+			first = false
+			pieces = append(pieces, tok)
+		}
+	}
+	var (
+		out    []flatWord
+		pieceN []int
+	)
+	for _, p := range pieces {
+		text := p.Text
+		start := time.Duration(p.Offsets.From) * time.Millisecond
+		end := time.Duration(p.Offsets.To) * time.Millisecond
+		startsWord := strings.HasPrefix(text, " ") || len(out) == 0
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		if !startsWord {
+			last := &out[len(out)-1]
+			last.Word += text
+			if end > last.End {
+				last.End = end
+			}
+			// Word probability is the mean over its tokens.
+			n := pieceN[len(pieceN)-1]
+			last.Probability = (last.Probability*float64(n) + p.P) / float64(n+1)
+			pieceN[len(pieceN)-1]++
+			continue
+		}
+		out = append(out, flatWord{Word: text, Start: start, End: end, Probability: p.P})
+		pieceN = append(pieceN, 1)
+	}
+	return out, nil
+}
+
+// isWhisperCppSpecial reports control tokens such as [_BEG_] or [_TT_150].
+func isWhisperCppSpecial(text string) bool {
+	text = strings.TrimSpace(text)
+	return strings.HasPrefix(text, "[_") && strings.HasSuffix(text, "]")
+}
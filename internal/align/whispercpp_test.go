@@ -0,0 +1,62 @@
+package align
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	return data
+}
+
+func TestParseWhisperCppJSON(t *testing.T) {
+	want := []flatWord{
+		{Word: "بسم", Start: 0, End: 620 * time.Millisecond},
+		{Word: "الله", Start: 620 * time.Millisecond, End: 1100 * time.Millisecond},
+		{Word: "الرحمن", Start: 1100 * time.Millisecond, End: 1800 * time.Millisecond},
+		{Word: "الرحيم", Start: 1800 * time.Millisecond, End: 2400 * time.Millisecond},
+	}
+	for _, name := range []string{"whispercpp_full.json", "whispercpp_segments.json"} {
+		words, err := parseWhisperCppJSON(readFixture(t, name))
+		if err != nil {
+			t.Fatalf("%s: parse failed: %v", name, err)
+		}
+		if len(words) != len(want) {
+			t.Fatalf("%s: expected %d words, got %+v", name, len(want), words)
+		}
+		for i := range want {
+			words[i].Probability = 0
+			if words[i] != want[i] {
+				t.Fatalf("%s: word %d: expected %+v, got %+v", name, i, want[i], words[i])
+			}
+		}
+	}
+}
+
+func TestWhisperCppOutputAligns(t *testing.T) {
+	whisperWords, err := parseWhisperCppJSON(readFixture(t, "whispercpp_full.json"))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	words := []string{"بِسْمِ", "اللَّهِ", "الرَّحْمَنِ", "الرَّحِيمِ"}
+	aligned, err := alignWords(words, whisperWords)
+	if err != nil {
+		t.Fatalf("align failed: %v", err)
+	}
+	if len(aligned) != len(words) {
+		t.Fatalf("expected %d timings, got %d", len(words), len(aligned))
+	}
+	if aligned[1].Start != 620*time.Millisecond || aligned[3].End != 2400*time.Millisecond {
+		t.Fatalf("unexpected timings: %+v", aligned)
+	}
+	if p := aligned[0].Probability; p < 0.89 || p > 0.9 {
+		t.Fatalf("expected mean token probability for merged word, got %v", p)
+	}
+}
@@ -0,0 +1,24 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+
+	"qgencodex/internal/ffmpeg"
+)
+
+// ExtractAudio writes the audio stream at streamIndex of inputPath to outputPath.
+func ExtractAudio(ctx context.Context, inputPath, outputPath string, streamIndex int, bitrate int) error {
+	if bitrate <= 0 {
+		bitrate = 128
+	}
+	args := []string{
+		"-y",
+		"-i", inputPath,
+		"-map", fmt.Sprintf("0:%d", streamIndex),
+		"-vn",
+		"-b:a", fmt.Sprintf("%dk", bitrate),
+		outputPath,
+	}
+	return ffmpeg.Run(ctx, args...)
+}
@@ -89,17 +89,28 @@ func readEnergies(ctx context.Context, audioPath string, sampleRate int, frameBy
 	if err := cmd.Start(); err != nil {
 		return nil, err
 	}
-	reader := bufio.NewReader(stdout)
+	energies, err := pcmEnergies(bufio.NewReader(stdout), frameBytes, frameSamples)
+	if err != nil {
+		_ = cmd.Wait()
+		return nil, fmt.Errorf("ffmpeg read failed: %w", err)
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("ffmpeg failed: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return energies, nil
+}
+
+// pcmEnergies returns the RMS energy of each full frame of mono s16le PCM read from r.
+func pcmEnergies(r io.Reader, frameBytes int, frameSamples int) ([]float64, error) {
 	frame := make([]byte, frameBytes)
 	energies := make([]float64, 0, 4096)
 	for {
-		n, err := io.ReadFull(reader, frame)
+		n, err := io.ReadFull(r, frame)
 		if err != nil {
 			if err == io.EOF || err == io.ErrUnexpectedEOF {
 				break
 			}
-			_ = cmd.Wait()
-			return nil, fmt.Errorf("ffmpeg read failed: %w", err)
+			return nil, err
 		}
 		if n < frameBytes {
 			break
@@ -114,9 +125,6 @@ func readEnergies(ctx context.Context, audioPath string, sampleRate int, frameBy
 		rms := math.Sqrt(sum / float64(frameSamples))
 		energies = append(energies, rms)
 	}
-	if err := cmd.Wait(); err != nil {
-		return nil, fmt.Errorf("ffmpeg failed: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
-	}
 	return energies, nil
 }
 
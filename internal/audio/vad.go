@@ -0,0 +1,248 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// VADConfig tunes energy-based voice activity detection. Zero values use defaults.
+type VADConfig struct {
+	// FrameMs is the analysis frame length in milliseconds.
+	FrameMs int
+	// MinSpeech drops speech bursts shorter than this (clicks, breaths).
+	MinSpeech time.Duration
+	// MinSilence bridges gaps shorter than this; only longer pauses are reported.
+	MinSilence time.Duration
+	// Hangover keeps speech active this long after energy falls below the threshold.
+	Hangover time.Duration
+	// ThresholdRatio places the onset threshold between the noise floor and speech level.
+	ThresholdRatio float64
+	// FloorDB is an absolute level (dBFS) below which frames are always silent.
+	FloorDB float64
+}
+
+// SpeechRegion is a span of detected speech with a 0-1 confidence.
+type SpeechRegion struct {
+	Start      time.Duration
+	End        time.Duration
+	Confidence float64
+}
+
+// VADResult holds speech regions and the silences between them.
+type VADResult struct {
+	Speech   []SpeechRegion
+	Silences []Silence
+}
+
+func (c VADConfig) withDefaults() VADConfig {
+	if c.FrameMs <= 0 {
+		c.FrameMs = 10
+	}
+	if c.MinSpeech <= 0 {
+		c.MinSpeech = 120 * time.Millisecond
+	}
+	if c.MinSilence <= 0 {
+		c.MinSilence = 200 * time.Millisecond
+	}
+	if c.Hangover <= 0 {
+		c.Hangover = 80 * time.Millisecond
+	}
+	if c.ThresholdRatio <= 0 || c.ThresholdRatio >= 1 {
+		c.ThresholdRatio = 0.3
+	}
+	if c.FloorDB == 0 {
+		c.FloorDB = -55
+	}
+	return c
+}
+
+// DetectVoiceActivity decodes audioPath to PCM and runs energy-based VAD on it.
+func DetectVoiceActivity(ctx context.Context, audioPath string, cfg VADConfig) (VADResult, error) {
+	cfg = cfg.withDefaults()
+	const sampleRate = 16000
+	frameSamples := sampleRate * cfg.FrameMs / 1000
+	if frameSamples <= 0 {
+		return VADResult{}, errors.New("invalid frame size")
+	}
+	energies, err := readEnergies(ctx, audioPath, sampleRate, frameSamples*2, frameSamples)
+	if err != nil {
+		return VADResult{}, err
+	}
+	if len(energies) == 0 {
+		return VADResult{}, errors.New("no energy frames extracted")
+	}
+	return AnalyzeEnergies(energies, cfg), nil
+}
+
+// AnalyzeEnergies classifies per-frame RMS energies (s16 scale) into speech and silence.
+func AnalyzeEnergies(energies []float64, cfg VADConfig) VADResult {
+	cfg = cfg.withDefaults()
+	if len(energies) == 0 {
+		return VADResult{}
+	}
+	frameDur := time.Duration(cfg.FrameMs) * time.Millisecond
+	total := time.Duration(len(energies)) * frameDur
+	levels := make([]float64, len(energies))
+	for i, e := range energies {
+		levels[i] = energyDB(e)
+	}
+	noise := percentile(levels, 0.10)
+	speech := percentile(levels, 0.95)
+
+	active := make([]bool, len(levels))
+	if speech-noise < 6 {
+		// Flat signal: either continuous speech or continuous silence.
+		for i := range active {
+			active[i] = levels[i] > cfg.FloorDB
+		}
+	} else {
+		markActiveFrames(levels, active, noise, speech, cfg, frameDur)
+	}
+
+	runs := activeRuns(active)
+	runs = bridgeShortGaps(runs, int(cfg.MinSilence/frameDur))
+	runs = dropShortRuns(runs, int(cfg.MinSpeech/frameDur))
+
+	result := VADResult{}
+	cursor := time.Duration(0)
+	for _, r := range runs {
+		start := time.Duration(r.start) * frameDur
+		end := time.Duration(r.end) * frameDur
+		if start-cursor >= cfg.MinSilence {
+			result.Silences = append(result.Silences, Silence{Start: cursor, End: start})
+		}
+		result.Speech = append(result.Speech, SpeechRegion{
+			Start:      start,
+			End:        end,
+			Confidence: regionConfidence(levels[r.start:r.end], noise, speech),
+		})
+		cursor = end
+	}
+	if total-cursor >= cfg.MinSilence {
+		result.Silences = append(result.Silences, Silence{Start: cursor, End: total})
+	}
+	return result
+}
+
+// markActiveFrames runs a hysteresis detector against an adaptive noise floor.
+// The floor falls quickly to quiet frames and rises slowly, so it tracks
+// background noise without following speech.
+func markActiveFrames(levels []float64, active []bool, noise, speech float64, cfg VADConfig, frameDur time.Duration) {
+	const riseDBPerSec = 3.0
+	rise := riseDBPerSec * frameDur.Seconds()
+	ceiling := noise + (speech-noise)*0.5
+	hangoverFrames := int(cfg.Hangover / frameDur)
+	floor := noise
+	on := false
+	hang := 0
+	for i, level := range levels {
+		if level < floor {
+			floor = 0.5*floor + 0.5*level
+		} else if floor+rise <= ceiling {
+			floor += rise
+		}
+		span := speech - floor
+		onThreshold := math.Max(floor+cfg.ThresholdRatio*span, cfg.FloorDB)
+		offThreshold := math.Max(floor+cfg.ThresholdRatio*0.6*span, cfg.FloorDB)
+		switch {
+		case !on && level >= onThreshold:
+			on = true
+			hang = hangoverFrames
+		case on && level >= offThreshold:
+			hang = hangoverFrames
+		case on && hang > 0:
+			hang--
+		case on:
+			on = false
+		}
+		active[i] = on
+	}
+}
+
+type frameRun struct {
+	start int
+	end   int
+}
+
+func activeRuns(active []bool) []frameRun {
+	var runs []frameRun
+	start := -1
+	for i, a := range active {
+		if a && start < 0 {
+			start = i
+		}
+		if !a && start >= 0 {
+			runs = append(runs, frameRun{start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		runs = append(runs, frameRun{start: start, end: len(active)})
+	}
+	return runs
+}
+
+func bridgeShortGaps(runs []frameRun, minGap int) []frameRun {
+	if len(runs) <= 1 {
+		return runs
+	}
+	out := []frameRun{runs[0]}
+	for _, r := range runs[1:] {
+		last := &out[len(out)-1]
+		if r.start-last.end < minGap {
+			last.end = r.end
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+func dropShortRuns(runs []frameRun, minLen int) []frameRun {
+	out := make([]frameRun, 0, len(runs))
+	for _, r := range runs {
+		if r.end-r.start < minLen {
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+func regionConfidence(levels []float64, noise, speech float64) float64 {
+	if len(levels) == 0 || speech <= noise {
+		return 0
+	}
+	sum := 0.0
+	for _, l := range levels {
+		v := (l - noise) / (speech - noise)
+		if v < 0 {
+			v = 0
+		}
+		if v > 1 {
+			v = 1
+		}
+		sum += v
+	}
+	return sum / float64(len(levels))
+}
+
+func energyDB(rms float64) float64 {
+	if rms < 1 {
+		rms = 1
+	}
+	return 20 * math.Log10(rms/32768)
+}
+
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	idx := int(p * float64(len(sorted)-1))
+	return sorted[idx]
+}
@@ -0,0 +1,123 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+const testSampleRate = 16000
+
+type pcmPart struct {
+	dur       time.Duration
+	amplitude float64
+}
+
+// synthPCM renders a deterministic mono s16le signal: a tone for speech parts
+// on top of low-level noise everywhere.
+func synthPCM(parts []pcmPart) []byte {
+	rng := rand.New(rand.NewSource(1))
+	var buf bytes.Buffer
+	n := 0
+	for _, p := range parts {
+		samples := int(p.dur.Seconds() * testSampleRate)
+		for i := 0; i < samples; i++ {
+			v := (rng.Float64()*2 - 1) * 60
+			v += p.amplitude * math.Sin(2*math.Pi*220*float64(n)/testSampleRate)
+			_ = binary.Write(&buf, binary.LittleEndian, int16(v))
+			n++
+		}
+	}
+	return buf.Bytes()
+}
+
+func analyzeSynth(t *testing.T, parts []pcmPart, cfg VADConfig) VADResult {
+	t.Helper()
+	frameSamples := testSampleRate * 10 / 1000
+	energies, err := pcmEnergies(bytes.NewReader(synthPCM(parts)), frameSamples*2, frameSamples)
+	if err != nil {
+		t.Fatalf("pcmEnergies failed: %v", err)
+	}
+	return AnalyzeEnergies(energies, cfg)
+}
+
+func within(got, want, tolerance time.Duration) bool {
+	d := got - want
+	if d < 0 {
+		d = -d
+	}
+	return d <= tolerance
+}
+
+func TestAnalyzeEnergiesFindsSpeechAndSilence(t *testing.T) {
+	result := analyzeSynth(t, []pcmPart{
+		{dur: 500 * time.Millisecond},
+		{dur: 1000 * time.Millisecond, amplitude: 8000},
+		{dur: 400 * time.Millisecond},
+		{dur: 600 * time.Millisecond, amplitude: 6000},
+		{dur: 500 * time.Millisecond},
+	}, VADConfig{})
+	if len(result.Speech) != 2 {
+		t.Fatalf("expected 2 speech regions, got %+v", result.Speech)
+	}
+	tol := 100 * time.Millisecond
+	if !within(result.Speech[0].Start, 500*time.Millisecond, tol) || !within(result.Speech[0].End, 1500*time.Millisecond, tol) {
+		t.Fatalf("unexpected first region: %+v", result.Speech[0])
+	}
+	if !within(result.Speech[1].Start, 1900*time.Millisecond, tol) || !within(result.Speech[1].End, 2500*time.Millisecond, tol) {
+		t.Fatalf("unexpected second region: %+v", result.Speech[1])
+	}
+	for _, r := range result.Speech {
+		if r.Confidence <= 0.5 || r.Confidence > 1 {
+			t.Fatalf("expected confident speech, got %v", r.Confidence)
+		}
+	}
+	if len(result.Silences) != 3 {
+		t.Fatalf("expected leading, middle and trailing silences, got %+v", result.Silences)
+	}
+	if result.Silences[0].Start != 0 {
+		t.Fatalf("expected leading silence at 0, got %v", result.Silences[0].Start)
+	}
+}
+
+func TestAnalyzeEnergiesBridgesShortGaps(t *testing.T) {
+	result := analyzeSynth(t, []pcmPart{
+		{dur: 300 * time.Millisecond},
+		{dur: 500 * time.Millisecond, amplitude: 8000},
+		{dur: 100 * time.Millisecond},
+		{dur: 500 * time.Millisecond, amplitude: 8000},
+		{dur: 300 * time.Millisecond},
+	}, VADConfig{MinSilence: 250 * time.Millisecond})
+	if len(result.Speech) != 1 {
+		t.Fatalf("expected short gap to be bridged, got %+v", result.Speech)
+	}
+}
+
+func TestAnalyzeEnergiesDropsShortBursts(t *testing.T) {
+	result := analyzeSynth(t, []pcmPart{
+		{dur: 500 * time.Millisecond},
+		{dur: 40 * time.Millisecond, amplitude: 9000},
+		{dur: 500 * time.Millisecond},
+		{dur: 800 * time.Millisecond, amplitude: 8000},
+		{dur: 500 * time.Millisecond},
+	}, VADConfig{MinSpeech: 150 * time.Millisecond, Hangover: 20 * time.Millisecond})
+	if len(result.Speech) != 1 {
+		t.Fatalf("expected click to be dropped, got %+v", result.Speech)
+	}
+	if !within(result.Speech[0].Start, 1040*time.Millisecond, 100*time.Millisecond) {
+		t.Fatalf("unexpected region start: %v", result.Speech[0].Start)
+	}
+}
+
+func TestAnalyzeEnergiesAllSilence(t *testing.T) {
+	result := analyzeSynth(t, []pcmPart{{dur: time.Second}}, VADConfig{})
+	if len(result.Speech) != 0 {
+		t.Fatalf("expected no speech, got %+v", result.Speech)
+	}
+	if len(result.Silences) != 1 || result.Silences[0].End != time.Second {
+		t.Fatalf("expected one full-length silence, got %+v", result.Silences)
+	}
+}
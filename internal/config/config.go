@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -34,24 +35,54 @@ type QuranAPIConfig struct {
 	Translation string `yaml:"translation"`
 	Reciter     string `yaml:"reciter"`
 	TimeoutSec  int    `yaml:"timeout_sec"`
+	// IndexPath is the recognition index; empty uses DefaultIndexPath.
+	IndexPath string `yaml:"index_path"`
 }
 
 type AudioConfig struct {
-	CDNBaseURL             string  `yaml:"cdn_base_url"`
-	BitrateKbps            int     `yaml:"bitrate_kbps"`
-	MaxConcurrent          int     `yaml:"max_concurrent"`
-	WordTiming             string  `yaml:"word_timing"`
-	WordOffsetMs           int     `yaml:"word_offset_ms"`
-	AutoWordOffset         bool    `yaml:"auto_word_offset"`
-	AutoWordOffsetWindowMs int     `yaml:"auto_word_offset_window_ms"`
-	PauseSensitive         bool    `yaml:"pause_sensitive"`
-	PauseDB                int     `yaml:"pause_db"`
-	PauseSec               float64 `yaml:"pause_sec"`
-	WhisperCmd             string  `yaml:"whisper_cmd"`
-	Language               string  `yaml:"language"`
-	TrimSilence            bool    `yaml:"trim_silence"`
-	SilenceDB              int     `yaml:"silence_db"`
-	SilenceSec             float64 `yaml:"silence_sec"`
+	CDNBaseURL             string        `yaml:"cdn_base_url"`
+	BitrateKbps            int           `yaml:"bitrate_kbps"`
+	MaxConcurrent          int           `yaml:"max_concurrent"`
+	WordTiming             string        `yaml:"word_timing"`
+	WordOffsetMs           int           `yaml:"word_offset_ms"`
+	AutoWordOffset         bool          `yaml:"auto_word_offset"`
+	AutoWordOffsetWindowMs int           `yaml:"auto_word_offset_window_ms"`
+	PauseSensitive         bool          `yaml:"pause_sensitive"`
+	PauseDB                int           `yaml:"pause_db"`
+	PauseSec               float64       `yaml:"pause_sec"`
+	PauseDetector          string        `yaml:"pause_detector"`
+	Aligner                string        `yaml:"aligner"`
+	WhisperCmd             string        `yaml:"whisper_cmd"`
+	Whisper                WhisperConfig `yaml:"whisper"`
+	WhisperCppCmd          string        `yaml:"whisper_cpp_cmd"`
+	WhisperCppModel        string        `yaml:"whisper_cpp_model"`
+	TranscribeURL          string        `yaml:"transcribe_url"`
+	TranscribeModel        string        `yaml:"transcribe_model"`
+	TranscribeAPIKey       string        `yaml:"transcribe_api_key"`
+	TranscribeTimeoutSec   int           `yaml:"transcribe_timeout_sec"`
+	TranscribeRetries      int           `yaml:"transcribe_retries"`
+	ChunkSec               int           `yaml:"chunk_sec"`
+	ChunkOverlapSec        float64       `yaml:"chunk_overlap_sec"`
+	TranscribeWorkers      int           `yaml:"transcribe_workers"`
+	AlignCache             bool          `yaml:"align_cache"`
+	AlignCacheDir          string        `yaml:"align_cache_dir"`
+	AlignReport            bool          `yaml:"align_report"`
+	MinAlignConfidence     float64       `yaml:"min_align_confidence"`
+	Language               string        `yaml:"language"`
+	TrimSilence            bool          `yaml:"trim_silence"`
+	SilenceDB              int           `yaml:"silence_db"`
+	SilenceSec             float64       `yaml:"silence_sec"`
+}
+
+// WhisperConfig holds model and decoding settings for the Whisper backends.
+type WhisperConfig struct {
+	Model     string   `yaml:"model"`
+	Device    string   `yaml:"device"`
+	Threads   int      `yaml:"threads"`
+	BeamSize  int      `yaml:"beam_size"`
+	Prompt    string   `yaml:"initial_prompt"` // full | first:N | none
+	VADFilter bool     `yaml:"vad_filter"`
+	ExtraArgs []string `yaml:"extra_args"`
 }
 
 type BackgroundConfig struct {
@@ -80,22 +111,29 @@ type BackgroundConfig struct {
 }
 
 type VideoConfig struct {
-	Resolution         string       `yaml:"resolution"`
-	DisplayMode        string       `yaml:"display_mode"`
-	Renderer           string       `yaml:"renderer"`
-	TranslationFont    string       `yaml:"translation_font"`
-	TranslationSpacing int          `yaml:"translation_spacing"`
-	Elongate           bool         `yaml:"elongate"`
-	ElongateCount      int          `yaml:"elongate_count"`
-	FadeInMs           int          `yaml:"fade_in_ms"`
-	FadeOutMs          int          `yaml:"fade_out_ms"`
-	Font               FontConfig   `yaml:"font"`
-	Glass              GlassConfig  `yaml:"glass"`
-	Reference          RefConfig    `yaml:"reference"`
-	Background         BgConfig     `yaml:"background"`
-	Margins            MarginConfig `yaml:"margins"`
-	LineSpacing        int          `yaml:"line_spacing"`
-	TextPosition       string       `yaml:"text_position"`
+	Resolution         string        `yaml:"resolution"`
+	DisplayMode        string        `yaml:"display_mode"`
+	Renderer           string        `yaml:"renderer"`
+	TranslationFont    string        `yaml:"translation_font"`
+	TranslationSpacing int           `yaml:"translation_spacing"`
+	Elongate           bool          `yaml:"elongate"`
+	ElongateCount      int           `yaml:"elongate_count"`
+	FadeInMs           int           `yaml:"fade_in_ms"`
+	FadeOutMs          int           `yaml:"fade_out_ms"`
+	Font               FontConfig    `yaml:"font"`
+	Glass              GlassConfig   `yaml:"glass"`
+	Karaoke            KaraokeConfig `yaml:"karaoke"`
+	Phrase             PhraseConfig  `yaml:"phrase"`
+	Chunk              ChunkConfig   `yaml:"chunk"`
+	LineByLine         LineConfig    `yaml:"line_by_line"`
+	Teleprompter       PromptConfig  `yaml:"teleprompter"`
+	Fit                FitConfig     `yaml:"fit"`
+	Glyphs             GlyphConfig   `yaml:"glyphs"`
+	Reference          RefConfig     `yaml:"reference"`
+	Background         BgConfig      `yaml:"background"`
+	Margins            MarginConfig  `yaml:"margins"`
+	LineSpacing        int           `yaml:"line_spacing"`
+	TextPosition       string        `yaml:"text_position"`
 }
 
 type FontConfig struct {
@@ -117,6 +155,68 @@ type GlassConfig struct {
 	Padding int     `yaml:"padding"`
 }
 
+// KaraokeConfig styles the current word in karaoke mode.
+type KaraokeConfig struct {
+	// Style is color, glow or underline.
+	Style string `yaml:"style"`
+	Color string `yaml:"color"`
+}
+
+// PhraseConfig controls how phrase mode chunks an ayah beyond its waqf signs.
+type PhraseConfig struct {
+	// MaxWords splits longer phrases evenly; 0 keeps them whole.
+	MaxWords int `yaml:"max_words"`
+	// PauseMs also ends a phrase at a gap this long between words; 0 disables.
+	PauseMs int `yaml:"pause_ms"`
+}
+
+// ChunkConfig sizes each chunk in chunk:auto mode: words are added while the
+// chunk is shorter than TargetMs and still fits on one line.
+type ChunkConfig struct {
+	TargetMs int `yaml:"target_ms"`
+	// MaxWords caps a chunk; 0 means no cap.
+	MaxWords int `yaml:"max_words"`
+}
+
+// LineConfig controls line-by-line mode.
+type LineConfig struct {
+	// Lines is how many wrapped lines are on screen at once.
+	Lines int `yaml:"lines"`
+	// Translation is whole (the ayah's translation throughout) or split
+	// (divided across the screens in proportion to their words).
+	Translation string `yaml:"translation"`
+}
+
+// PromptConfig styles the scrolling teleprompter mode.
+type PromptConfig struct {
+	// Focus is where the line being recited sits, as a fraction of the height.
+	Focus          float64 `yaml:"focus"`
+	HighlightColor string  `yaml:"highlight_color"`
+	// DimOpacity is the opacity of ayahs already recited.
+	DimOpacity float64 `yaml:"dim_opacity"`
+}
+
+// FitConfig keeps a long ayah and its translation between the top and bottom
+// margins.
+type FitConfig struct {
+	// Mode is shrink (smaller font down to MinSize, then pages), page (timed
+	// pages at the configured size) or off.
+	Mode    string `yaml:"mode"`
+	MinSize int    `yaml:"min_size"`
+}
+
+// GlyphConfig handles characters the font has no glyph for.
+type GlyphConfig struct {
+	// OnMissing is warn, fail or ignore.
+	OnMissing string `yaml:"on_missing"`
+	// Fallbacks are font files or families tried in order when video.font
+	// lacks a character of the text.
+	Fallbacks []string `yaml:"fallbacks"`
+	// Substitute replaces characters before rendering; keys and values are
+	// literal text or U+XXXX code points.
+	Substitute map[string]string `yaml:"substitute"`
+}
+
 type RefConfig struct {
 	Enabled bool   `yaml:"enabled"`
 	Color   string `yaml:"color"`
@@ -178,11 +278,23 @@ func Default() Config {
 			PauseSensitive:         false,
 			PauseDB:                -35,
 			PauseSec:               0.20,
+			PauseDetector:          "ffmpeg",
+			Aligner:                "whisper",
 			WhisperCmd:             "whisper",
-			Language:               "ar",
-			TrimSilence:            false,
-			SilenceDB:              -35,
-			SilenceSec:             0.30,
+			Whisper: WhisperConfig{
+				BeamSize: 5,
+				Prompt:   "full",
+			},
+			WhisperCppCmd:        "whisper-cli",
+			TranscribeModel:      "whisper-1",
+			TranscribeTimeoutSec: 300,
+			TranscribeRetries:    3,
+			ChunkOverlapSec:      5,
+			TranscribeWorkers:    2,
+			Language:             "ar",
+			TrimSilence:          false,
+			SilenceDB:            -35,
+			SilenceSec:           0.30,
 		},
 		Background: BackgroundConfig{
 			Provider:           "pexels",
@@ -233,6 +345,33 @@ func Default() Config {
 				Alpha:   0.20,
 				Padding: 18,
 			},
+			Karaoke: KaraokeConfig{
+				Style: "color",
+				Color: "#FFD700",
+			},
+			Phrase: PhraseConfig{
+				PauseMs: 500,
+			},
+			Chunk: ChunkConfig{
+				TargetMs: 1500,
+				MaxWords: 4,
+			},
+			LineByLine: LineConfig{
+				Lines:       2,
+				Translation: "split",
+			},
+			Teleprompter: PromptConfig{
+				Focus:          0.4,
+				HighlightColor: "#FFD700",
+				DimOpacity:     0.45,
+			},
+			Fit: FitConfig{
+				Mode:    "shrink",
+				MinSize: 40,
+			},
+			Glyphs: GlyphConfig{
+				OnMissing: "warn",
+			},
 			Reference: RefConfig{
 				Enabled: true,
 				Color:   "#FFFFFF",
@@ -281,6 +420,24 @@ func DefaultConfigPath() (string, error) {
 	return filepath.Join(home, DefaultAppDirName, DefaultConfigName), nil
 }
 
+// DefaultAlignCacheDir returns the default alignment cache directory.
+func DefaultAlignCacheDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, DefaultAppDirName, "cache", "align"), nil
+}
+
+// DefaultIndexPath returns the default recognition index path for edition.
+func DefaultIndexPath(edition string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, DefaultAppDirName, "index", edition+".gob"), nil
+}
+
 // LoadOrCreate loads configuration from path, creating defaults if missing.
 func LoadOrCreate(path string) (*Config, bool, error) {
 	if path == "" {
@@ -333,6 +490,11 @@ func (c *Config) ExpandEnv() {
 	c.QuranAPI.Edition = expandEnv(c.QuranAPI.Edition)
 	c.QuranAPI.Translation = expandEnv(c.QuranAPI.Translation)
 	c.QuranAPI.Reciter = expandEnv(c.QuranAPI.Reciter)
+	c.QuranAPI.IndexPath = expandEnv(c.QuranAPI.IndexPath)
+	c.Audio.TranscribeURL = expandEnv(c.Audio.TranscribeURL)
+	c.Audio.TranscribeAPIKey = expandEnv(c.Audio.TranscribeAPIKey)
+	c.Audio.AlignCacheDir = expandEnv(c.Audio.AlignCacheDir)
+	c.Audio.Whisper.Model = expandEnv(c.Audio.Whisper.Model)
 	c.Background.PexelsAPIKey = expandEnv(c.Background.PexelsAPIKey)
 	c.Background.PexelsBaseURL = expandEnv(c.Background.PexelsBaseURL)
 	c.Background.PixabayAPIKey = expandEnv(c.Background.PixabayAPIKey)
@@ -385,11 +547,90 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("unsupported audio.word_timing: %s", c.Audio.WordTiming)
 		}
 	}
-	switch strings.ToLower(c.Video.DisplayMode) {
-	case "sequential", "repeat", "sequential-repeat", "repeat-2x2", "repeat-two-by-two", "repeat-pair", "word-by-word", "two-by-two", "two", "pair", "2x2":
-	default:
+	if c.Audio.Aligner != "" {
+		switch strings.ToLower(c.Audio.Aligner) {
+		case "whisper", "whisper-cpp":
+		case "http":
+			if c.Audio.TranscribeURL == "" {
+				return errors.New("audio.transcribe_url is required for the http aligner")
+			}
+		default:
+			return fmt.Errorf("unsupported audio.aligner: %s", c.Audio.Aligner)
+		}
+	}
+	if err := c.Audio.Whisper.validate(); err != nil {
+		return err
+	}
+	if c.Audio.ChunkSec < 0 {
+		return errors.New("audio.chunk_sec must not be negative")
+	}
+	if c.Audio.ChunkSec > 0 {
+		if c.Audio.ChunkOverlapSec < 0 || c.Audio.ChunkOverlapSec*2 >= float64(c.Audio.ChunkSec) {
+			return errors.New("audio.chunk_overlap_sec must be between 0 and half of audio.chunk_sec")
+		}
+		if c.Audio.TranscribeWorkers < 1 {
+			return errors.New("audio.transcribe_workers must be positive")
+		}
+	}
+	if c.Audio.MinAlignConfidence < 0 || c.Audio.MinAlignConfidence > 1 {
+		return errors.New("audio.min_align_confidence must be between 0 and 1")
+	}
+	if c.Audio.PauseDetector != "" {
+		switch strings.ToLower(c.Audio.PauseDetector) {
+		case "ffmpeg", "vad":
+		default:
+			return fmt.Errorf("unsupported audio.pause_detector: %s", c.Audio.PauseDetector)
+		}
+	}
+	if _, err := ParseDisplayMode(c.Video.DisplayMode); err != nil {
 		return fmt.Errorf("unsupported video.display_mode: %s", c.Video.DisplayMode)
 	}
+	if c.Video.Karaoke.Style != "" {
+		switch strings.ToLower(c.Video.Karaoke.Style) {
+		case "color", "glow", "underline":
+		default:
+			return fmt.Errorf("unsupported video.karaoke.style: %s", c.Video.Karaoke.Style)
+		}
+	}
+	if c.Video.Phrase.MaxWords < 0 || c.Video.Phrase.PauseMs < 0 {
+		return fmt.Errorf("video.phrase.max_words and pause_ms must not be negative")
+	}
+	if c.Video.Chunk.MaxWords < 0 || c.Video.Chunk.TargetMs < 0 {
+		return fmt.Errorf("video.chunk.max_words and target_ms must not be negative")
+	}
+	if f := c.Video.Teleprompter.Focus; f < 0 || f >= 1 {
+		return fmt.Errorf("video.teleprompter.focus must be between 0 and 1")
+	}
+	if o := c.Video.Teleprompter.DimOpacity; o < 0 || o > 1 {
+		return fmt.Errorf("video.teleprompter.dim_opacity must be between 0 and 1")
+	}
+	if c.Video.Fit.Mode != "" {
+		switch strings.ToLower(c.Video.Fit.Mode) {
+		case "shrink", "page", "off":
+		default:
+			return fmt.Errorf("unsupported video.fit.mode: %s", c.Video.Fit.Mode)
+		}
+	}
+	if c.Video.Fit.MinSize < 0 {
+		return fmt.Errorf("video.fit.min_size must not be negative")
+	}
+	if c.Video.Glyphs.OnMissing != "" {
+		switch strings.ToLower(c.Video.Glyphs.OnMissing) {
+		case "warn", "fail", "ignore":
+		default:
+			return fmt.Errorf("unsupported video.glyphs.on_missing: %s", c.Video.Glyphs.OnMissing)
+		}
+	}
+	if c.Video.LineByLine.Lines < 0 {
+		return fmt.Errorf("video.line_by_line.lines must not be negative")
+	}
+	if c.Video.LineByLine.Translation != "" {
+		switch strings.ToLower(c.Video.LineByLine.Translation) {
+		case "whole", "split":
+		default:
+			return fmt.Errorf("unsupported video.line_by_line.translation: %s", c.Video.LineByLine.Translation)
+		}
+	}
 	if c.Background.Quality != "" {
 		switch strings.ToLower(c.Background.Quality) {
 		case "best", "hd", "sd", "smallest":
@@ -406,7 +647,7 @@ func (c *Config) Validate() error {
 	}
 	if c.Video.Renderer != "" {
 		switch strings.ToLower(c.Video.Renderer) {
-		case "drawtext", "ass", "subtitles":
+		case "drawtext", "ass", "subtitles", "image":
 		default:
 			return fmt.Errorf("unsupported video.renderer: %s", c.Video.Renderer)
 		}
@@ -425,3 +666,23 @@ func (c *Config) Validate() error {
 	}
 	return nil
 }
+
+func (w WhisperConfig) validate() error {
+	if w.Threads < 0 {
+		return errors.New("audio.whisper.threads must not be negative")
+	}
+	if w.BeamSize < 0 {
+		return errors.New("audio.whisper.beam_size must not be negative")
+	}
+	prompt := strings.ToLower(strings.TrimSpace(w.Prompt))
+	switch {
+	case prompt == "", prompt == "full", prompt == "none":
+	case strings.HasPrefix(prompt, "first:"):
+		if n, err := strconv.Atoi(strings.TrimPrefix(prompt, "first:")); err != nil || n <= 0 {
+			return fmt.Errorf("audio.whisper.initial_prompt: invalid word count in %q", w.Prompt)
+		}
+	default:
+		return fmt.Errorf("unsupported audio.whisper.initial_prompt: %s (full|first:N|none)", w.Prompt)
+	}
+	return nil
+}
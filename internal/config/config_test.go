@@ -56,3 +56,144 @@ func TestValidateRenderer(t *testing.T) {
 		t.Fatalf("expected error for unsupported renderer")
 	}
 }
+
+func TestValidatePauseDetector(t *testing.T) {
+	cfg := Default()
+	cfg.Audio.PauseDetector = "vad"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected vad detector to validate, got %v", err)
+	}
+	cfg.Audio.PauseDetector = "magic"
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for unsupported pause detector")
+	}
+}
+
+func TestValidateAligner(t *testing.T) {
+	cfg := Default()
+	cfg.Audio.Aligner = "whisper-cpp"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected whisper-cpp aligner to validate, got %v", err)
+	}
+	cfg.Audio.Aligner = "http"
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for http aligner without transcribe_url")
+	}
+	cfg.Audio.TranscribeURL = "http://asr.lan:8000"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected http aligner to validate, got %v", err)
+	}
+	cfg.Audio.Aligner = "vosk"
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for unsupported aligner")
+	}
+}
+
+func TestValidateChunking(t *testing.T) {
+	cfg := Default()
+	cfg.Audio.ChunkSec = 600
+	cfg.Audio.ChunkOverlapSec = float64(cfg.Audio.ChunkSec)
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for overlap as long as the chunk")
+	}
+	cfg.Audio.ChunkSec = 0
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected disabled chunking to ignore overlap, got %v", err)
+	}
+}
+
+func TestValidateWhisperPrompt(t *testing.T) {
+	cfg := Default()
+	for _, prompt := range []string{"full", "none", "first:12", ""} {
+		cfg.Audio.Whisper.Prompt = prompt
+		if err := cfg.Validate(); err != nil {
+			t.Fatalf("expected initial_prompt %q to validate, got %v", prompt, err)
+		}
+	}
+	for _, prompt := range []string{"first:0", "first:x", "last:3"} {
+		cfg.Audio.Whisper.Prompt = prompt
+		if err := cfg.Validate(); err == nil {
+			t.Fatalf("expected error for initial_prompt %q", prompt)
+		}
+	}
+}
+
+func TestValidateKaraokeStyle(t *testing.T) {
+	cfg := Default()
+	cfg.Video.DisplayMode = "karaoke"
+	cfg.Video.Karaoke.Style = "glow"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected karaoke glow to validate, got %v", err)
+	}
+	cfg.Video.Karaoke.Style = "blink"
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for unsupported karaoke style")
+	}
+}
+
+func TestValidatePhraseLimits(t *testing.T) {
+	cfg := Default()
+	cfg.Video.DisplayMode = "phrase"
+	cfg.Video.Phrase.MaxWords = 6
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected phrase mode to validate, got %v", err)
+	}
+	cfg.Video.Phrase.PauseMs = -1
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for negative pause_ms")
+	}
+}
+
+func TestValidateLineByLineTranslation(t *testing.T) {
+	cfg := Default()
+	cfg.Video.DisplayMode = "line-by-line"
+	cfg.Video.LineByLine.Translation = "whole"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected line-by-line to validate, got %v", err)
+	}
+	cfg.Video.LineByLine.Translation = "interleaved"
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for unsupported translation layout")
+	}
+}
+
+func TestValidateTeleprompter(t *testing.T) {
+	cfg := Default()
+	cfg.Video.DisplayMode = "mushaf-flow"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected teleprompter to validate, got %v", err)
+	}
+	cfg.Video.Teleprompter.Focus = 1.2
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for focus outside the frame")
+	}
+	cfg.Video.Teleprompter.Focus = 0.4
+	cfg.Video.Teleprompter.DimOpacity = -0.1
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for negative dim_opacity")
+	}
+}
+
+func TestValidateFitMode(t *testing.T) {
+	cfg := Default()
+	cfg.Video.Fit.Mode = "page"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected page fit to validate, got %v", err)
+	}
+	cfg.Video.Fit.Mode = "scale"
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for unsupported fit mode")
+	}
+}
+
+func TestValidateGlyphsOnMissing(t *testing.T) {
+	cfg := Default()
+	cfg.Video.Glyphs.OnMissing = "fail"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected fail to validate, got %v", err)
+	}
+	cfg.Video.Glyphs.OnMissing = "abort"
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for unsupported on_missing")
+	}
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Display mode layouts.
+const (
+	ModeSequential  = "sequential"
+	ModeChunk       = "chunk"
+	ModePhrase      = "phrase"
+	ModeKaraoke     = "karaoke"
+	ModeProgressive = "progressive"
+	ModeLineByLine  = "line-by-line"
+	ModePrompter    = "teleprompter"
+)
+
+// DisplayMode is a resolved video.display_mode or --mode value.
+type DisplayMode struct {
+	// Kind is one of the Mode* layouts.
+	Kind string
+	// Chunk is the number of words shown at a time in chunk modes; 0 sizes
+	// each chunk from video.chunk.
+	Chunk int
+	// Repeat plays each ayah twice, or each pair for chunk modes.
+	Repeat bool
+}
+
+// WordTimed reports whether the mode follows per-word timings.
+func (m DisplayMode) WordTimed() bool {
+	return m.Kind != ModeSequential
+}
+
+// displayModes maps every mode name and alias to its layout. chunk:N and
+// chunk:auto are parsed separately.
+var displayModes = map[string]DisplayMode{
+	"sequential":        {Kind: ModeSequential},
+	"repeat":            {Kind: ModeSequential, Repeat: true},
+	"sequential-repeat": {Kind: ModeSequential, Repeat: true},
+	"word-by-word":      {Kind: ModeChunk, Chunk: 1},
+	"word":              {Kind: ModeChunk, Chunk: 1},
+	"two-by-two":        {Kind: ModeChunk, Chunk: 2},
+	"two":               {Kind: ModeChunk, Chunk: 2},
+	"pair":              {Kind: ModeChunk, Chunk: 2},
+	"2x2":               {Kind: ModeChunk, Chunk: 2},
+	"repeat-2x2":        {Kind: ModeChunk, Chunk: 2, Repeat: true},
+	"repeat-two-by-two": {Kind: ModeChunk, Chunk: 2, Repeat: true},
+	"repeat-pair":       {Kind: ModeChunk, Chunk: 2, Repeat: true},
+	"phrase":            {Kind: ModePhrase},
+	"karaoke":           {Kind: ModeKaraoke},
+	"progressive":       {Kind: ModeProgressive},
+	"line-by-line":      {Kind: ModeLineByLine},
+	"lines":             {Kind: ModeLineByLine},
+	"teleprompter":      {Kind: ModePrompter},
+	"mushaf-flow":       {Kind: ModePrompter},
+}
+
+// ParseDisplayMode resolves a mode name, one of its aliases, chunk:N or
+// chunk:auto.
+func ParseDisplayMode(name string) (DisplayMode, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if mode, ok := displayModes[key]; ok {
+		return mode, nil
+	}
+	if size, ok := strings.CutPrefix(key, ModeChunk+":"); ok {
+		if size == "auto" {
+			return DisplayMode{Kind: ModeChunk}, nil
+		}
+		if n, err := strconv.Atoi(size); err == nil && n > 0 {
+			return DisplayMode{Kind: ModeChunk, Chunk: n}, nil
+		}
+	}
+	return DisplayMode{}, fmt.Errorf("unsupported display mode: %s", name)
+}
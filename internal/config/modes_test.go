@@ -0,0 +1,27 @@
+package config
+
+import "testing"
+
+func TestParseDisplayMode(t *testing.T) {
+	cases := map[string]DisplayMode{
+		"Sequential":   {Kind: ModeSequential},
+		"repeat":       {Kind: ModeSequential, Repeat: true},
+		"word":         {Kind: ModeChunk, Chunk: 1},
+		"2x2":          {Kind: ModeChunk, Chunk: 2},
+		"repeat-pair":  {Kind: ModeChunk, Chunk: 2, Repeat: true},
+		"chunk:3":      {Kind: ModeChunk, Chunk: 3},
+		" chunk:auto ": {Kind: ModeChunk},
+		"karaoke":      {Kind: ModeKaraoke},
+	}
+	for name, want := range cases {
+		got, err := ParseDisplayMode(name)
+		if err != nil || got != want {
+			t.Fatalf("%q: expected %+v, got %+v (%v)", name, want, got, err)
+		}
+	}
+	for _, name := range []string{"", "chunk", "chunk:0", "chunk:-2", "chunk:x", "three"} {
+		if _, err := ParseDisplayMode(name); err == nil {
+			t.Fatalf("expected error for %q", name)
+		}
+	}
+}
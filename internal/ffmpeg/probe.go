@@ -0,0 +1,150 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Stream describes a single stream reported by ffprobe.
+type Stream struct {
+	Index     int
+	CodecType string
+	CodecName string
+	Width     int
+	Height    int
+	Channels  int
+	// Rotation is the display rotation in degrees (0, 90, 180 or 270).
+	Rotation int
+}
+
+// MediaInfo describes the stream layout of a media file.
+type MediaInfo struct {
+	Streams  []Stream
+	Duration float64
+}
+
+// Video returns the first real video stream (cover art is ignored).
+func (m MediaInfo) Video() (Stream, bool) {
+	for _, s := range m.Streams {
+		if s.CodecType == "video" {
+			return s, true
+		}
+	}
+	return Stream{}, false
+}
+
+// Audio returns the first audio stream.
+func (m MediaInfo) Audio() (Stream, bool) {
+	for _, s := range m.Streams {
+		if s.CodecType == "audio" {
+			return s, true
+		}
+	}
+	return Stream{}, false
+}
+
+// DisplaySize returns the frame size after rotation metadata is applied.
+func (s Stream) DisplaySize() (int, int) {
+	if s.Rotation == 90 || s.Rotation == 270 {
+		return s.Height, s.Width
+	}
+	return s.Width, s.Height
+}
+
+// ProbeStreams returns the stream layout of a media file using ffprobe.
+func ProbeStreams(ctx context.Context, path string) (MediaInfo, error) {
+	if _, err := exec.LookPath("ffprobe"); err != nil {
+		return MediaInfo{}, fmt.Errorf("ffprobe not found in PATH")
+	}
+	cmd := exec.CommandContext(ctx, "ffprobe",
+		"-v", "error",
+		"-show_streams",
+		"-show_format",
+		"-of", "json",
+		path,
+	)
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return MediaInfo{}, fmt.Errorf("ffprobe failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseProbeOutput(stdout.Bytes())
+}
+
+type probeOutput struct {
+	Streams []probeStream `json:"streams"`
+	Format  struct {
+		Duration string `json:"duration"`
+	} `json:"format"`
+}
+
+type probeStream struct {
+	Index       int               `json:"index"`
+	CodecType   string            `json:"codec_type"`
+	CodecName   string            `json:"codec_name"`
+	Width       int               `json:"width"`
+	Height      int               `json:"height"`
+	Channels    int               `json:"channels"`
+	Tags        map[string]string `json:"tags"`
+	Disposition map[string]int    `json:"disposition"`
+	SideData    []struct {
+		Rotation float64 `json:"rotation"`
+	} `json:"side_data_list"`
+}
+
+func parseProbeOutput(data []byte) (MediaInfo, error) {
+	var out probeOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		return MediaInfo{}, fmt.Errorf("parse ffprobe output: %w", err)
+	}
+	info := MediaInfo{}
+	if out.Format.Duration != "" {
+		if d, err := strconv.ParseFloat(out.Format.Duration, 64); err == nil {
+			info.Duration = d
+		}
+	}
+	for _, s := range out.Streams {
+		if s.CodecType == "video" && s.Disposition["attached_pic"] == 1 {
+			// Album art embedded in audio files is not a real video track.
+			continue
+		}
+		info.Streams = append(info.Streams, Stream{
+			Index:     s.Index,
+			CodecType: s.CodecType,
+			CodecName: s.CodecName,
+			Width:     s.Width,
+			Height:    s.Height,
+			Channels:  s.Channels,
+			Rotation:  streamRotation(s),
+		})
+	}
+	return info, nil
+}
+
+func streamRotation(s probeStream) int {
+	rotation := 0.0
+	if value, ok := s.Tags["rotate"]; ok {
+		if r, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+			rotation = r
+		}
+	}
+	for _, sd := range s.SideData {
+		if sd.Rotation != 0 {
+			// Display matrix rotation is counter-clockwise; the rotate tag is clockwise.
+			rotation = -sd.Rotation
+			break
+		}
+	}
+	deg := int(rotation) % 360
+	if deg < 0 {
+		deg += 360
+	}
+	return deg
+}
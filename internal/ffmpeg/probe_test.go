@@ -0,0 +1,66 @@
+package ffmpeg
+
+import "testing"
+
+func TestParseProbeOutputRotatedVideo(t *testing.T) {
+	data := []byte(`{
+		"streams": [
+			{"index": 0, "codec_type": "video", "codec_name": "h264", "width": 1920, "height": 1080,
+			 "side_data_list": [{"side_data_type": "Display Matrix", "rotation": -90}]},
+			{"index": 1, "codec_type": "audio", "codec_name": "aac", "channels": 2}
+		],
+		"format": {"duration": "12.500000"}
+	}`)
+	info, err := parseProbeOutput(data)
+	if err != nil {
+		t.Fatalf("parseProbeOutput failed: %v", err)
+	}
+	if info.Duration != 12.5 {
+		t.Fatalf("unexpected duration: %v", info.Duration)
+	}
+	video, ok := info.Video()
+	if !ok {
+		t.Fatalf("expected video stream")
+	}
+	if video.Rotation != 90 {
+		t.Fatalf("expected rotation 90, got %d", video.Rotation)
+	}
+	w, h := video.DisplaySize()
+	if w != 1080 || h != 1920 {
+		t.Fatalf("expected rotated display size 1080x1920, got %dx%d", w, h)
+	}
+	audio, ok := info.Audio()
+	if !ok || audio.Index != 1 {
+		t.Fatalf("expected audio stream at index 1")
+	}
+}
+
+func TestParseProbeOutputIgnoresCoverArt(t *testing.T) {
+	data := []byte(`{
+		"streams": [
+			{"index": 0, "codec_type": "audio", "codec_name": "mp3", "channels": 2},
+			{"index": 1, "codec_type": "video", "codec_name": "mjpeg", "width": 600, "height": 600,
+			 "disposition": {"attached_pic": 1}}
+		],
+		"format": {"duration": "3.0"}
+	}`)
+	info, err := parseProbeOutput(data)
+	if err != nil {
+		t.Fatalf("parseProbeOutput failed: %v", err)
+	}
+	if _, ok := info.Video(); ok {
+		t.Fatalf("expected cover art to be ignored")
+	}
+}
+
+func TestParseProbeOutputRotateTag(t *testing.T) {
+	data := []byte(`{"streams": [{"index": 0, "codec_type": "video", "width": 1280, "height": 720, "tags": {"rotate": "270"}}], "format": {}}`)
+	info, err := parseProbeOutput(data)
+	if err != nil {
+		t.Fatalf("parseProbeOutput failed: %v", err)
+	}
+	video, _ := info.Video()
+	if video.Rotation != 270 {
+		t.Fatalf("expected rotation 270, got %d", video.Rotation)
+	}
+}
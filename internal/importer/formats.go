@@ -0,0 +1,186 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"qgencodex/internal/align"
+)
+
+var (
+	srtTimeRe  = regexp.MustCompile(`(\d+):(\d{2}):(\d{2})[,.](\d{1,3})\s*-->\s*(\d+):(\d{2}):(\d{2})[,.](\d{1,3})`)
+	htmlTagRe  = regexp.MustCompile(`<[^>]*>`)
+	lrcTagRe   = regexp.MustCompile(`^\[(\d+):(\d{2}(?:\.\d+)?)\]`)
+	lrcWordRe  = regexp.MustCompile(`<(\d+):(\d{2}(?:\.\d+)?)>([^<]*)`)
+	lrcMetaRe  = regexp.MustCompile(`^\[[a-zA-Z]+:.*\]$`)
+	spaceRunRe = regexp.MustCompile(`\s+`)
+)
+
+// ParseSRT reads SubRip subtitles.
+func ParseSRT(data []byte) ([]Cue, error) {
+	var cues []Cue
+	blocks := regexp.MustCompile(`\r?\n\s*\r?\n`).Split(strings.TrimSpace(string(data)), -1)
+	for _, block := range blocks {
+		lines := strings.Split(strings.ReplaceAll(block, "\r\n", "\n"), "\n")
+		for i, line := range lines {
+			m := srtTimeRe.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			text := strings.Join(lines[i+1:], " ")
+			text = spaceRunRe.ReplaceAllString(htmlTagRe.ReplaceAllString(text, ""), " ")
+			cues = append(cues, Cue{
+				Start: clockDuration(m[1], m[2], m[3], m[4]),
+				End:   clockDuration(m[5], m[6], m[7], m[8]),
+				Text:  strings.TrimSpace(text),
+			})
+			break
+		}
+	}
+	return cues, nil
+}
+
+// ParseLRC reads LRC lyrics, including enhanced <mm:ss.xx> word tags. Lines
+// have no end time; Map closes them at the next cue.
+func ParseLRC(data []byte) ([]Cue, error) {
+	var cues []Cue
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || lrcMetaRe.MatchString(line) && !lrcTagRe.MatchString(line) {
+			continue
+		}
+		var starts []time.Duration
+		for {
+			m := lrcTagRe.FindStringSubmatch(line)
+			if m == nil {
+				break
+			}
+			starts = append(starts, minSecDuration(m[1], m[2]))
+			line = strings.TrimSpace(line[len(m[0]):])
+		}
+		if len(starts) == 0 {
+			continue
+		}
+		var words []Word
+		for _, m := range lrcWordRe.FindAllStringSubmatch(line, -1) {
+			if w := strings.TrimSpace(m[3]); w != "" {
+				words = append(words, Word{Text: w, Start: minSecDuration(m[1], m[2])})
+			}
+		}
+		for i := range words {
+			if i+1 < len(words) {
+				words[i].End = words[i+1].Start
+			}
+		}
+		text := spaceRunRe.ReplaceAllString(strings.TrimSpace(lrcWordRe.ReplaceAllString(line, "$3")), " ")
+		for _, start := range starts {
+			cues = append(cues, Cue{Start: start, End: start, Text: text, Words: words})
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	sortCues(cues)
+	return cues, nil
+}
+
+// ParseAudacityLabels reads an exported Audacity label track:
+// "start<TAB>end<TAB>label" in seconds. Spectral selection lines are skipped.
+func ParseAudacityLabels(data []byte) ([]Cue, error) {
+	var cues []Cue
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimRight(sc.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "\\") {
+			continue
+		}
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: expected start and end separated by tabs", lineNo)
+		}
+		start, err := strconv.ParseFloat(strings.TrimSpace(fields[0]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		end, err := strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		label := ""
+		if len(fields) == 3 {
+			label = strings.TrimSpace(fields[2])
+		}
+		cues = append(cues, Cue{Start: seconds(start), End: seconds(end), Text: label})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	sortCues(cues)
+	return cues, nil
+}
+
+// ParseWhisperJSON reads Whisper, faster-whisper/WhisperX or whisper.cpp JSON.
+// Word timestamps are used when present, otherwise segment text.
+func ParseWhisperJSON(data []byte) ([]Cue, error) {
+	words, err := align.ParseTranscriptJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(words) > 0 {
+		cues := make([]Cue, 0, len(words))
+		for _, w := range words {
+			cues = append(cues, Cue{
+				Start: w.Start,
+				End:   w.End,
+				Text:  w.Word,
+				Words: []Word{{Text: w.Word, Start: w.Start, End: w.End}},
+			})
+		}
+		return cues, nil
+	}
+	var result struct {
+		Segments []struct {
+			Start float64 `json:"start"`
+			End   float64 `json:"end"`
+			Text  string  `json:"text"`
+		} `json:"segments"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	cues := make([]Cue, 0, len(result.Segments))
+	for _, s := range result.Segments {
+		cues = append(cues, Cue{Start: seconds(s.Start), End: seconds(s.End), Text: strings.TrimSpace(s.Text)})
+	}
+	return cues, nil
+}
+
+func clockDuration(h, m, s, ms string) time.Duration {
+	hh, _ := strconv.Atoi(h)
+	mm, _ := strconv.Atoi(m)
+	ss, _ := strconv.Atoi(s)
+	for len(ms) < 3 {
+		ms += "0"
+	}
+	milli, _ := strconv.Atoi(ms)
+	return time.Duration(hh)*time.Hour + time.Duration(mm)*time.Minute + time.Duration(ss)*time.Second + time.Duration(milli)*time.Millisecond
+}
+
+func minSecDuration(m, s string) time.Duration {
+	mm, _ := strconv.Atoi(m)
+	ss, _ := strconv.ParseFloat(s, 64)
+	return time.Duration(mm)*time.Minute + seconds(ss)
+}
+
+func seconds(sec float64) time.Duration {
+	return time.Duration(sec * float64(time.Second))
+}
@@ -0,0 +1,105 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Cue is one timed piece of text from an external timing source. Words holds
+// word timestamps when the source has them (Whisper JSON, enhanced LRC).
+type Cue struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+	Words []Word
+}
+
+type Word struct {
+	Text  string
+	Start time.Duration
+	End   time.Duration
+}
+
+// Formats accepted by Load; "json" is the native timing file and is not
+// handled by this package.
+const (
+	FormatAuto     = "auto"
+	FormatJSON     = "json"
+	FormatSRT      = "srt"
+	FormatLRC      = "lrc"
+	FormatAudacity = "audacity"
+	FormatWhisper  = "whisper"
+)
+
+// DetectFormat guesses a timing file's format from its extension and, for
+// JSON, its top-level keys.
+func DetectFormat(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".srt":
+		return FormatSRT, nil
+	case ".lrc":
+		return FormatLRC, nil
+	case ".txt", ".labels":
+		return FormatAudacity, nil
+	case ".json":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		var keys map[string]json.RawMessage
+		if err := json.Unmarshal(data, &keys); err != nil {
+			return "", fmt.Errorf("parse %s: %w", path, err)
+		}
+		if _, ok := keys["timings"]; ok {
+			return FormatJSON, nil
+		}
+		return FormatWhisper, nil
+	default:
+		return "", fmt.Errorf("cannot detect timing format of %s; pass --timings-format", path)
+	}
+}
+
+// Load parses an external timing file into cues.
+func Load(path string, format string) ([]Cue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	var cues []Cue
+	switch strings.ToLower(format) {
+	case FormatSRT:
+		cues, err = ParseSRT(data)
+	case FormatLRC:
+		cues, err = ParseLRC(data)
+	case FormatAudacity:
+		cues, err = ParseAudacityLabels(data)
+	case FormatWhisper:
+		cues, err = ParseWhisperJSON(data)
+	default:
+		return nil, fmt.Errorf("unsupported timing format: %s", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(cues) == 0 {
+		return nil, fmt.Errorf("%s: no timed cues found", path)
+	}
+	return cues, nil
+}
+
+// Text joins all cue text, e.g. for identifying the recited range.
+func Text(cues []Cue) string {
+	parts := make([]string, 0, len(cues))
+	for _, c := range cues {
+		if t := strings.TrimSpace(c.Text); t != "" {
+			parts = append(parts, t)
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,105 @@
+package importer
+
+import (
+	"testing"
+	"time"
+
+	"qgencodex/internal/quran"
+)
+
+func ikhlas() []quran.Verse {
+	meta := quran.SurahMeta{Number: 112}
+	return []quran.Verse{
+		{NumberInSurah: 1, Text: "قُلْ هُوَ اللَّهُ أَحَدٌ", SurahMeta: meta},
+		{NumberInSurah: 2, Text: "اللَّهُ الصَّمَدُ", SurahMeta: meta},
+	}
+}
+
+func TestParseSRTAndMapWords(t *testing.T) {
+	data := []byte("1\r\n00:00:00,500 --> 00:00:03,000\r\nقل هو الله أحد\r\n\r\n2\r\n00:00:03,400 --> 00:00:05,000\r\n<i>الله الصمد</i>\r\n")
+	cues, err := ParseSRT(data)
+	if err != nil {
+		t.Fatalf("ParseSRT failed: %v", err)
+	}
+	if len(cues) != 2 || cues[1].Text != "الله الصمد" || cues[1].Start != 3400*time.Millisecond {
+		t.Fatalf("unexpected cues: %+v", cues)
+	}
+	timings, err := Map(cues, ikhlas(), 0)
+	if err != nil {
+		t.Fatalf("Map failed: %v", err)
+	}
+	if len(timings) != 2 {
+		t.Fatalf("expected 2 ayah timings, got %d", len(timings))
+	}
+	if timings[0].Start != 500*time.Millisecond || timings[1].Start != 3400*time.Millisecond || timings[1].End != 5*time.Second {
+		t.Fatalf("unexpected ayah bounds: %v-%v, %v-%v", timings[0].Start, timings[0].End, timings[1].Start, timings[1].End)
+	}
+	if len(timings[0].WordTimings) != 4 || timings[0].WordTimings[0].Source != "matched" {
+		t.Fatalf("unexpected word timings: %+v", timings[0].WordTimings)
+	}
+}
+
+func TestParseLRCEnhancedWords(t *testing.T) {
+	data := []byte("[ar:Mishary]\n[00:01.00]<00:01.00>قل <00:01.40>هو <00:01.80>الله <00:02.50>أحد\n[00:03.20]الله الصمد\n")
+	cues, err := ParseLRC(data)
+	if err != nil {
+		t.Fatalf("ParseLRC failed: %v", err)
+	}
+	if len(cues) != 2 || len(cues[0].Words) != 4 || cues[0].Text != "قل هو الله أحد" {
+		t.Fatalf("unexpected cues: %+v", cues)
+	}
+	timings, err := Map(cues, ikhlas(), 5*time.Second)
+	if err != nil {
+		t.Fatalf("Map failed: %v", err)
+	}
+	words := timings[0].WordTimings
+	if words[1].Start != 1400*time.Millisecond || words[1].End != 1800*time.Millisecond {
+		t.Fatalf("expected word tag times, got %+v", words[1])
+	}
+	if words[3].End != 3200*time.Millisecond {
+		t.Fatalf("expected last word to end at next line, got %v", words[3].End)
+	}
+	if timings[1].End != 5*time.Second {
+		t.Fatalf("expected last line to end at audio end, got %v", timings[1].End)
+	}
+}
+
+func TestAudacityNumericLabelsAreAyahBoundaries(t *testing.T) {
+	data := []byte("0.250000\t2.900000\t1\n\\\t0.000000\t0.000000\n3.100000\t3.100000\t٢\n")
+	cues, err := ParseAudacityLabels(data)
+	if err != nil {
+		t.Fatalf("ParseAudacityLabels failed: %v", err)
+	}
+	timings, err := Map(cues, ikhlas(), 6*time.Second)
+	if err != nil {
+		t.Fatalf("Map failed: %v", err)
+	}
+	if len(timings) != 2 || timings[1].Verse.NumberInSurah != 2 {
+		t.Fatalf("unexpected timings: %+v", timings)
+	}
+	if timings[0].End != 2900*time.Millisecond || timings[1].Start != 3100*time.Millisecond || timings[1].End != 6*time.Second {
+		t.Fatalf("unexpected bounds: %v-%v, %v-%v", timings[0].Start, timings[0].End, timings[1].Start, timings[1].End)
+	}
+	if len(timings[1].WordTimings) != 2 {
+		t.Fatalf("expected estimated word timings, got %+v", timings[1].WordTimings)
+	}
+}
+
+func TestParseWhisperJSONSegmentsAndWords(t *testing.T) {
+	withWords := []byte(`{"text":"الله الصمد","segments":[{"start":0,"end":1.5,"text":" الله الصمد","words":[{"word":" الله","start":0.1,"end":0.6,"probability":0.9},{"word":" الصمد","start":0.6,"end":1.5,"probability":0.8}]}]}`)
+	cues, err := ParseWhisperJSON(withWords)
+	if err != nil {
+		t.Fatalf("ParseWhisperJSON failed: %v", err)
+	}
+	if len(cues) != 2 || cues[1].Words[0].Start != 600*time.Millisecond {
+		t.Fatalf("unexpected word cues: %+v", cues)
+	}
+	segmentsOnly := []byte(`{"segments":[{"start":0,"end":1.5,"text":" الله الصمد"}]}`)
+	cues, err = ParseWhisperJSON(segmentsOnly)
+	if err != nil {
+		t.Fatalf("ParseWhisperJSON failed: %v", err)
+	}
+	if len(cues) != 1 || cues[0].Text != "الله الصمد" || cues[0].End != 1500*time.Millisecond {
+		t.Fatalf("unexpected segment cues: %+v", cues)
+	}
+}
@@ -0,0 +1,203 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"qgencodex/internal/align"
+	"qgencodex/internal/quran"
+	"qgencodex/internal/render"
+)
+
+// openCueLength closes the last open cue (LRC line, point label) when the audio
+// length is unknown.
+const openCueLength = 3 * time.Second
+
+// splitWordProbability marks word times estimated inside a multi-word cue, so
+// they score below words with real timestamps.
+const splitWordProbability = 0.5
+
+// Map matches cues to the fetched verses. Cues labelled only with ayah numbers
+// (or nothing) are treated as ayah boundaries; otherwise the cue text is
+// aligned word by word against the verse text.
+func Map(cues []Cue, verses []quran.Verse, audioDuration time.Duration) ([]render.Timing, error) {
+	if len(cues) == 0 {
+		return nil, errors.New("no cues")
+	}
+	if len(verses) == 0 {
+		return nil, errors.New("no verses")
+	}
+	cues = append([]Cue(nil), cues...)
+	sortCues(cues)
+	closeOpenCues(cues, audioDuration)
+	if AyahLabelsOnly(cues) {
+		return mapBoundaries(cues, verses)
+	}
+	return mapWords(cues, verses)
+}
+
+func sortCues(cues []Cue) {
+	sort.SliceStable(cues, func(i, j int) bool { return cues[i].Start < cues[j].Start })
+}
+
+// closeOpenCues gives cues without an end (LRC lines, point labels) the start
+// of the next cue, and fills missing word ends the same way.
+func closeOpenCues(cues []Cue, audioDuration time.Duration) {
+	for i := range cues {
+		c := &cues[i]
+		if c.End <= c.Start {
+			switch {
+			case i+1 < len(cues) && cues[i+1].Start > c.Start:
+				c.End = cues[i+1].Start
+			case audioDuration > c.Start:
+				c.End = audioDuration
+			default:
+				c.End = c.Start + openCueLength
+			}
+		}
+		for j := range c.Words {
+			w := &c.Words[j]
+			if w.End <= w.Start {
+				w.End = c.End
+			}
+		}
+	}
+}
+
+// AyahLabelsOnly reports whether cues carry only ayah numbers (or no text),
+// i.e. they mark ayah boundaries rather than recited words.
+func AyahLabelsOnly(cues []Cue) bool {
+	for _, c := range cues {
+		text := strings.TrimSpace(c.Text)
+		if text == "" {
+			continue
+		}
+		if _, ok := labelNumber(text); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// labelNumber parses Western or Arabic-Indic digits.
+func labelNumber(text string) (int, bool) {
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(text) {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r >= '٠' && r <= '٩':
+			b.WriteRune('0' + (r - '٠'))
+		case r >= '۰' && r <= '۹':
+			b.WriteRune('0' + (r - '۰'))
+		default:
+			return 0, false
+		}
+	}
+	n, err := strconv.Atoi(b.String())
+	return n, err == nil
+}
+
+func mapBoundaries(cues []Cue, verses []quran.Verse) ([]render.Timing, error) {
+	byAyah := make(map[int]int, len(verses))
+	for i, v := range verses {
+		byAyah[v.NumberInSurah] = i
+	}
+	timings := make([]render.Timing, 0, len(cues))
+	next := 0
+	for i, c := range cues {
+		idx := next
+		if n, ok := labelNumber(c.Text); ok {
+			vi, found := byAyah[n]
+			if !found {
+				return nil, fmt.Errorf("cue %d: ayah %d is not in the requested range", i+1, n)
+			}
+			idx = vi
+		}
+		if idx >= len(verses) {
+			return nil, fmt.Errorf("cue %d: more labels than ayahs (%d)", i+1, len(verses))
+		}
+		verse := verses[idx]
+		timings = append(timings, render.Timing{
+			Verse:       verse,
+			Start:       c.Start,
+			End:         c.End,
+			WordTimings: render.SplitWordTimings(strings.Fields(verse.Text), c.Start, c.End),
+		})
+		next = idx + 1
+	}
+	return timings, nil
+}
+
+func mapWords(cues []Cue, verses []quran.Verse) ([]render.Timing, error) {
+	var transcribed []align.WordTiming
+	for _, c := range cues {
+		if len(c.Words) > 0 {
+			for _, w := range c.Words {
+				transcribed = append(transcribed, align.WordTiming{Word: w.Text, Start: w.Start, End: w.End})
+			}
+			continue
+		}
+		fields := strings.Fields(c.Text)
+		for _, wt := range render.SplitWordTimings(fields, c.Start, c.End) {
+			word := align.WordTiming{Word: wt.Word, Start: wt.Start, End: wt.End}
+			if len(fields) > 1 {
+				word.Probability = splitWordProbability
+			}
+			transcribed = append(transcribed, word)
+		}
+	}
+	return MapWords(transcribed, verses)
+}
+
+// MapWords aligns timed words from any transcription to the verse text and
+// derives each ayah's bounds from its words.
+func MapWords(transcribed []align.WordTiming, verses []quran.Verse) ([]render.Timing, error) {
+	words := make([]string, 0, 512)
+	verseIndex := make([]int, 0, 512)
+	for i, v := range verses {
+		for _, w := range strings.Fields(v.Text) {
+			words = append(words, w)
+			verseIndex = append(verseIndex, i)
+		}
+	}
+	aligned, err := align.AlignWords(words, transcribed)
+	if err != nil {
+		return nil, err
+	}
+	perVerse := make([][]render.WordTiming, len(verses))
+	for i, wt := range aligned {
+		if i >= len(verseIndex) {
+			break
+		}
+		vi := verseIndex[i]
+		perVerse[vi] = append(perVerse[vi], render.WordTiming{
+			Word:        wt.Word,
+			Start:       wt.Start,
+			End:         wt.End,
+			Source:      string(wt.Source),
+			Confidence:  wt.Confidence(),
+			Probability: wt.Probability,
+		})
+	}
+	timings := make([]render.Timing, 0, len(verses))
+	for i, v := range verses {
+		wts := perVerse[i]
+		if len(wts) == 0 {
+			continue
+		}
+		start, end := wts[0].Start, wts[len(wts)-1].End
+		if end < start {
+			end = start
+		}
+		timings = append(timings, render.Timing{Verse: v, Start: start, End: end, WordTimings: wts})
+	}
+	if len(timings) == 0 {
+		return nil, errors.New("no cues matched the verses")
+	}
+	return timings, nil
+}
@@ -0,0 +1,49 @@
+package recognize
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"qgencodex/internal/align"
+)
+
+// BackendRecognizer transcribes with an align.Backend such as whisper.cpp.
+type BackendRecognizer struct {
+	Backend align.Backend
+}
+
+func NewBackendRecognizer(backend align.Backend) *BackendRecognizer {
+	return &BackendRecognizer{Backend: backend}
+}
+
+func (b *BackendRecognizer) Available() bool {
+	return b.Backend != nil && b.Backend.Available()
+}
+
+func (b *BackendRecognizer) Transcribe(ctx context.Context, audioPath string, language string) (string, error) {
+	if !b.Available() {
+		return "", errors.New("transcription backend not available")
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	words, err := b.Backend.TranscribeWords(ctx, audioPath, language)
+	if err != nil {
+		return "", err
+	}
+	parts := make([]string, 0, len(words))
+	for _, w := range words {
+		if word := strings.TrimSpace(w.Word); word != "" {
+			parts = append(parts, word)
+		}
+	}
+	if len(parts) == 0 {
+		return "", errors.New("empty transcription")
+	}
+	return strings.Join(parts, " "), nil
+}
+
+func (b *BackendRecognizer) Identify(ctx context.Context, audioPath string, language string, matcher *Matcher) (Result, string, error) {
+	return Identify(ctx, b, audioPath, language, matcher)
+}
@@ -9,6 +9,8 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"qgencodex/internal/align"
 )
 
 type Result struct {
@@ -17,8 +19,19 @@ type Result struct {
 	EndAyah   int
 }
 
+// Transcriber turns recitation audio into plain text.
+type Transcriber interface {
+	Available() bool
+	Transcribe(ctx context.Context, audioPath string, language string) (string, error)
+}
+
 type WhisperRecognizer struct {
-	Cmd string
+	Cmd     string
+	Options align.WhisperOptions
+	// Logf, when set, receives the effective command line of each run.
+	Logf func(format string, args ...any)
+	// Warnf, when set, is told when options are ignored or dropped.
+	Warnf func(format string, args ...any)
 }
 
 func NewWhisperRecognizer(cmd string) *WhisperRecognizer {
@@ -52,17 +65,24 @@ func (w *WhisperRecognizer) Transcribe(ctx context.Context, audioPath string, la
 		"--output_format", "json",
 		"--output_dir", outputDir,
 		"--task", "transcribe",
-		audioPath,
 	}
+	baseArgs = append(baseArgs, w.Options.ModelArgs()...)
+	baseArgs = append(baseArgs, audioPath)
+	// The recitation is unknown here, so no initial prompt.
 	advancedArgs := append([]string{}, baseArgs...)
-	advancedArgs = append(advancedArgs,
-		"--temperature", "0",
-		"--beam_size", "5",
-		"--best_of", "5",
-	)
-	if err := runWhisper(ctx, w.Cmd, advancedArgs); err != nil {
+	advancedArgs = append(advancedArgs, w.Options.DecodingArgs(w.Cmd, "")...)
+	if w.Options.VADFilter && !align.SupportsVAD(w.Cmd) && w.Warnf != nil {
+		w.Warnf("%s does not support --vad_filter; ignoring whisper.vad_filter", w.Cmd)
+	}
+	if err := w.run(ctx, advancedArgs); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if w.Warnf != nil {
+			w.Warnf("%s failed with decoding options: %v; retrying without beam size and VAD", w.Cmd, err)
+		}
 		_ = os.Remove(jsonPath)
-		if err := runWhisper(ctx, w.Cmd, baseArgs); err != nil {
+		if err := w.run(ctx, baseArgs); err != nil {
 			return "", err
 		}
 	}
@@ -87,10 +107,15 @@ type whisperTranscript struct {
 }
 
 func (w *WhisperRecognizer) Identify(ctx context.Context, audioPath string, language string, matcher *Matcher) (Result, string, error) {
+	return Identify(ctx, w, audioPath, language, matcher)
+}
+
+// Identify transcribes audioPath with t and matches the transcript to a verse range.
+func Identify(ctx context.Context, t Transcriber, audioPath string, language string, matcher *Matcher) (Result, string, error) {
 	if matcher == nil {
 		return Result{}, "", errors.New("matcher is nil")
 	}
-	transcript, err := w.Transcribe(ctx, audioPath, language)
+	transcript, err := t.Transcribe(ctx, audioPath, language)
 	if err != nil {
 		return Result{}, "", err
 	}
@@ -101,9 +126,13 @@ func (w *WhisperRecognizer) Identify(ctx context.Context, audioPath string, lang
 	return result, transcript, nil
 }
 
-func runWhisper(ctx context.Context, cmd string, args []string) error {
-	c := exec.CommandContext(ctx, cmd, args...)
-	c.Stdout = os.Stdout
+func (w *WhisperRecognizer) run(ctx context.Context, args []string) error {
+	if w.Logf != nil {
+		w.Logf("Running %s", align.CommandLine(w.Cmd, args))
+	}
+	c := exec.CommandContext(ctx, w.Cmd, args...)
+	// Progress goes to stderr so it never mixes with the command's own output.
+	c.Stdout = os.Stderr
 	c.Stderr = os.Stderr
 	return c.Run()
 }
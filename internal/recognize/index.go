@@ -0,0 +1,244 @@
+package recognize
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	indexVersion = 1
+	// DefaultGramSize is the n-gram length used by BuildIndex.
+	DefaultGramSize = 3
+	// maxPostings skips n-grams too common to locate a passage (stop phrases).
+	maxPostings = 400
+	// shortlistRegions bounds how many regions get the full local alignment.
+	shortlistRegions = 8
+)
+
+// Index maps normalized token n-grams to their positions in the Quran and
+// keeps every surah's tokens, so identification runs offline.
+type Index struct {
+	Version int
+	Edition string
+	N       int
+	Surahs  map[int]IndexedSurah
+	Grams   map[string][]Posting
+}
+
+// IndexedSurah holds the normalized tokens of a surah and the ayah of each token.
+type IndexedSurah struct {
+	Tokens []string
+	Ayahs  []int
+}
+
+// Posting is one occurrence of an n-gram; Pos is the token offset in the surah.
+type Posting struct {
+	Surah int
+	Ayah  int
+	Pos   int
+}
+
+// BuildIndex fetches all 114 surahs from corpus and indexes their n-grams.
+func BuildIndex(ctx context.Context, corpus QuranCorpus, edition string, n int) (*Index, error) {
+	if corpus == nil {
+		return nil, errors.New("corpus is nil")
+	}
+	if n <= 0 {
+		n = DefaultGramSize
+	}
+	ix := &Index{
+		Version: indexVersion,
+		Edition: edition,
+		N:       n,
+		Surahs:  make(map[int]IndexedSurah, 114),
+		Grams:   make(map[string][]Posting),
+	}
+	for surah := 1; surah <= 114; surah++ {
+		ayahs, err := corpus.FetchSurah(ctx, surah)
+		if err != nil {
+			return nil, fmt.Errorf("fetch surah %d: %w", surah, err)
+		}
+		ix.add(surah, ayahs)
+	}
+	return ix, nil
+}
+
+func (ix *Index) add(surah int, ayahs []Ayah) {
+	var s IndexedSurah
+	for i, ayah := range ayahs {
+		number := ayah.NumberInSurah
+		if number == 0 {
+			number = i + 1
+		}
+		for _, tok := range normalizeTokens(ayah.Text) {
+			s.Tokens = append(s.Tokens, tok)
+			s.Ayahs = append(s.Ayahs, number)
+		}
+	}
+	ix.Surahs[surah] = s
+	for pos := 0; pos+ix.N <= len(s.Tokens); pos++ {
+		gram := strings.Join(s.Tokens[pos:pos+ix.N], " ")
+		ix.Grams[gram] = append(ix.Grams[gram], Posting{Surah: surah, Ayah: s.Ayahs[pos], Pos: pos})
+	}
+}
+
+// Save writes the index in gob format.
+func (ix *Index) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(f).Encode(ix); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadIndex reads an index written by Save.
+func LoadIndex(path string) (*Index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var ix Index
+	if err := gob.NewDecoder(f).Decode(&ix); err != nil {
+		return nil, fmt.Errorf("read index %s: %w", path, err)
+	}
+	if ix.Version != indexVersion {
+		return nil, fmt.Errorf("index %s has version %d, rebuild it with 'quranvideo index build'", path, ix.Version)
+	}
+	return &ix, nil
+}
+
+// FetchSurah serves the indexed text, so an Index is also an offline corpus.
+func (ix *Index) FetchSurah(ctx context.Context, surah int) ([]Ayah, error) {
+	s, ok := ix.Surahs[surah]
+	if !ok {
+		return nil, fmt.Errorf("surah %d is not indexed", surah)
+	}
+	var ayahs []Ayah
+	for i, tok := range s.Tokens {
+		if len(ayahs) == 0 || ayahs[len(ayahs)-1].NumberInSurah != s.Ayahs[i] {
+			ayahs = append(ayahs, Ayah{NumberInSurah: s.Ayahs[i], Text: tok})
+			continue
+		}
+		ayahs[len(ayahs)-1].Text += " " + tok
+	}
+	return ayahs, nil
+}
+
+// region is a token span of a surah shortlisted for local alignment.
+type region struct {
+	Surah int
+	Start int
+	End   int
+}
+
+// shortlist votes n-gram hits by surah and alignment diagonal and returns the
+// best supported regions, widened to fit the whole transcript.
+func (ix *Index) shortlist(needles []string, expectedSurah int) []region {
+	if len(needles) < ix.N {
+		return nil
+	}
+	bucket := len(needles)/2 + 1
+	type key struct{ surah, diag int }
+	votes := make(map[key]int)
+	for i := 0; i+ix.N <= len(needles); i++ {
+		postings := ix.Grams[strings.Join(needles[i:i+ix.N], " ")]
+		if len(postings) > maxPostings {
+			continue
+		}
+		for _, p := range postings {
+			if expectedSurah > 0 && p.Surah != expectedSurah {
+				continue
+			}
+			// Offset by len(needles) so the diagonal is never negative.
+			diag := (p.Pos - i + len(needles)) / bucket
+			votes[key{p.Surah, diag}]++
+		}
+	}
+	keys := make([]key, 0, len(votes))
+	for k := range votes {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		if votes[keys[a]] != votes[keys[b]] {
+			return votes[keys[a]] > votes[keys[b]]
+		}
+		if keys[a].surah != keys[b].surah {
+			return keys[a].surah < keys[b].surah
+		}
+		return keys[a].diag < keys[b].diag
+	})
+	if len(keys) > shortlistRegions {
+		keys = keys[:shortlistRegions]
+	}
+	margin := len(needles)/2 + bucket
+	regions := make([]region, 0, len(keys))
+	for _, k := range keys {
+		start := k.diag*bucket - len(needles) - margin
+		end := (k.diag+1)*bucket + margin
+		if start < 0 {
+			start = 0
+		}
+		if n := len(ix.Surahs[k.surah].Tokens); end > n {
+			end = n
+		}
+		if start < end {
+			regions = append(regions, region{Surah: k.surah, Start: start, End: end})
+		}
+	}
+	return mergeRegions(regions)
+}
+
+func mergeRegions(regions []region) []region {
+	sort.Slice(regions, func(a, b int) bool {
+		if regions[a].Surah != regions[b].Surah {
+			return regions[a].Surah < regions[b].Surah
+		}
+		return regions[a].Start < regions[b].Start
+	})
+	var out []region
+	for _, r := range regions {
+		if last := len(out) - 1; last >= 0 && out[last].Surah == r.Surah && r.Start <= out[last].End {
+			if r.End > out[last].End {
+				out[last].End = r.End
+			}
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// matches aligns needles against the shortlisted regions only.
+func (ix *Index) matches(needles []string, expectedSurah int) []matchCandidate {
+	var found []matchCandidate
+	for _, r := range ix.shortlist(needles, expectedSurah) {
+		s := ix.Surahs[r.Surah]
+		candidate, ok := matchTokens(needles, s.Tokens[r.Start:r.End], s.Ayahs[r.Start:r.End])
+		if !ok {
+			continue
+		}
+		candidate.Surah = r.Surah
+		found = append(found, candidate)
+	}
+	return found
+}
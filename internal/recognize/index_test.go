@@ -0,0 +1,139 @@
+package recognize
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"qgencodex/internal/align"
+)
+
+type fakeCorpus map[int][]string
+
+func (c fakeCorpus) FetchSurah(ctx context.Context, surah int) ([]Ayah, error) {
+	var ayahs []Ayah
+	for i, text := range c[surah] {
+		ayahs = append(ayahs, Ayah{NumberInSurah: i + 1, Text: text})
+	}
+	return ayahs, nil
+}
+
+func testCorpus() fakeCorpus {
+	return fakeCorpus{
+		112: {"قُلْ هُوَ اللَّهُ أَحَدٌ", "اللَّهُ الصَّمَدُ", "لَمْ يَلِدْ وَلَمْ يُولَدْ", "وَلَمْ يَكُن لَّهُ كُفُوًا أَحَدٌ"},
+		113: {"قُلْ أَعُوذُ بِرَبِّ الْفَلَقِ", "مِن شَرِّ مَا خَلَقَ", "وَمِن شَرِّ غَاسِقٍ إِذَا وَقَبَ", "وَمِن شَرِّ النَّفَّاثَاتِ فِي الْعُقَدِ", "وَمِن شَرِّ حَاسِدٍ إِذَا حَسَدَ"},
+		114: {"قُلْ أَعُوذُ بِرَبِّ النَّاسِ", "مَلِكِ النَّاسِ", "إِلَٰهِ النَّاسِ", "مِن شَرِّ الْوَسْوَاسِ الْخَنَّاسِ", "الَّذِي يُوَسْوِسُ فِي صُدُورِ النَّاسِ", "مِنَ الْجِنَّةِ وَالنَّاسِ"},
+	}
+}
+
+func TestIndexIdentifiesOffline(t *testing.T) {
+	ix, err := BuildIndex(context.Background(), testCorpus(), "quran-simple", 0)
+	if err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "index.gob")
+	if err := ix.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, err := LoadIndex(path)
+	if err != nil {
+		t.Fatalf("LoadIndex failed: %v", err)
+	}
+	if loaded.Edition != "quran-simple" || len(loaded.Grams) == 0 {
+		t.Fatalf("unexpected loaded index: edition %q, %d grams", loaded.Edition, len(loaded.Grams))
+	}
+
+	matcher := Matcher{Index: loaded}
+	result, err := matcher.Identify(context.Background(), "من شر غاسق اذا وقب ومن شر النفاثات في العقد ومن شر حاسد")
+	if err != nil {
+		t.Fatalf("Identify failed: %v", err)
+	}
+	if result != (Result{Surah: 113, StartAyah: 3, EndAyah: 5}) {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if regions := loaded.shortlist(normalizeTokens("ملك الناس اله الناس من شر الوسواس"), 0); len(regions) == 0 || regions[0].Surah != 114 {
+		t.Fatalf("expected surah 114 in the shortlist, got %+v", regions)
+	}
+}
+
+func TestIndexFallsBackToIndexedText(t *testing.T) {
+	ix, err := BuildIndex(context.Background(), testCorpus(), "quran-simple", 0)
+	if err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+	// Too short for a trigram, so the full scan runs over the indexed text.
+	matcher := Matcher{Index: ix, ExpectedSurah: 112}
+	result, err := matcher.Identify(context.Background(), "الله الصمد")
+	if err != nil {
+		t.Fatalf("Identify failed: %v", err)
+	}
+	if result.Surah != 112 || result.StartAyah != 2 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+func timedWords(start time.Duration, text string) []align.WordTiming {
+	var words []align.WordTiming
+	for _, w := range strings.Fields(text) {
+		words = append(words, align.WordTiming{Word: w, Start: start, End: start + 400*time.Millisecond})
+		start += 500 * time.Millisecond
+	}
+	return words
+}
+
+func TestIdentifyPassagesSplitsAtSurahChange(t *testing.T) {
+	ix, err := BuildIndex(context.Background(), testCorpus(), "quran-simple", 0)
+	if err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+	var words []align.WordTiming
+	words = append(words, timedWords(0, "قل اعوذ برب الفلق من شر ما خلق")...)
+	words = append(words, timedWords(7*time.Second, "ومن شر غاسق اذا وقب")...)
+	words = append(words, timedWords(12*time.Second, "الله اكبر")...)
+	words = append(words, timedWords(20*time.Second, "قل هو الله احد الله الصمد")...)
+	passages, err := IdentifyPassages(context.Background(), words, &Matcher{Index: ix}, 2*time.Second)
+	if err != nil {
+		t.Fatalf("IdentifyPassages failed: %v", err)
+	}
+	if len(passages) != 2 {
+		t.Fatalf("expected 2 passages, got %+v", passages)
+	}
+	if passages[0].Result != (Result{Surah: 113, StartAyah: 1, EndAyah: 3}) || passages[0].Start != 0 || len(passages[0].Words) != 13 {
+		t.Fatalf("unexpected first passage: %+v", passages[0])
+	}
+	if passages[1].Result != (Result{Surah: 112, StartAyah: 1, EndAyah: 2}) || passages[1].Start != 20*time.Second {
+		t.Fatalf("unexpected second passage: %+v", passages[1])
+	}
+}
+
+func TestCandidatesRankedWithAyahTimes(t *testing.T) {
+	ix, err := BuildIndex(context.Background(), testCorpus(), "quran-simple", 0)
+	if err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+	matcher := &Matcher{Corpus: testCorpus()}
+	candidates, err := matcher.Candidates(context.Background(), "قل اعوذ برب الناس ملك الناس", 3)
+	if err != nil {
+		t.Fatalf("Candidates failed: %v", err)
+	}
+	if len(candidates) < 2 || candidates[0].Surah != 114 || !candidates[0].Reliable {
+		t.Fatalf("expected surah 114 first, got %+v", candidates)
+	}
+	if candidates[0].Confidence != 1 || candidates[1].Confidence >= candidates[0].Confidence {
+		t.Fatalf("unexpected confidences: %v, %v", candidates[0].Confidence, candidates[1].Confidence)
+	}
+	if candidates[1].Surah != 113 || candidates[1].Matches != 3 {
+		t.Fatalf("expected the surah 113 opening as runner-up, got %+v", candidates[1])
+	}
+
+	words := append(timedWords(0, "قل اعوذ برب الناس"), timedWords(3*time.Second, "ملك الناس")...)
+	times, err := (&Matcher{Index: ix}).AyahTimes(context.Background(), candidates[0].Result, words)
+	if err != nil {
+		t.Fatalf("AyahTimes failed: %v", err)
+	}
+	if len(times) != 2 || times[1].Ayah != 2 || times[1].Start != 3*time.Second || times[0].End != 1900*time.Millisecond {
+		t.Fatalf("unexpected ayah times: %+v", times)
+	}
+}
@@ -3,29 +3,98 @@ package recognize
 import (
 	"context"
 	"errors"
+	"math"
+	"sort"
 	"strings"
 	"unicode"
 )
 
 type Matcher struct {
 	Corpus QuranCorpus
+	// Index, when set, shortlists candidate regions offline before the local
+	// alignment and serves as the corpus for the full scan.
+	Index *Index
 	// ExpectedSurah optionally narrows search to a single surah (1-114).
 	ExpectedSurah int
 }
 
 func (m *Matcher) Identify(ctx context.Context, text string) (Result, error) {
-	if m.Corpus == nil {
-		return Result{}, errors.New("corpus is nil")
+	candidates, err := m.Candidates(ctx, text, 1)
+	if err != nil {
+		return Result{}, err
+	}
+	var best matchCandidate
+	if len(candidates) > 0 {
+		best = candidates[0].match
+	}
+	if err := checkMatch(best, best.needles); err != nil {
+		return Result{}, err
+	}
+	return best.result(), nil
+}
+
+// Candidate is a ranked identification with its match statistics.
+type Candidate struct {
+	Result
+	Matches  int
+	Length   int
+	Score    int
+	Coverage float64
+	// Confidence is the alignment score relative to a perfect match (0-1).
+	Confidence float64
+	// Reliable reports whether the candidate passes the thresholds Identify uses.
+	Reliable bool
+
+	match matchCandidate
+}
+
+// Candidates returns up to n ranked matches for text, best first (one per
+// surah range). It only fails when text has nothing to match.
+func (m *Matcher) Candidates(ctx context.Context, text string, n int) ([]Candidate, error) {
+	corpus := m.Corpus
+	if m.Index != nil {
+		corpus = m.Index
+	}
+	if corpus == nil {
+		return nil, errors.New("corpus is nil")
 	}
 	needles := normalizeTokens(text)
 	if len(needles) == 0 {
-		return Result{}, errors.New("empty normalized text")
+		return nil, errors.New("empty normalized text")
+	}
+	var found []matchCandidate
+	if m.Index != nil {
+		found = m.Index.matches(needles, m.ExpectedSurah)
+		sortCandidates(found)
+		if len(found) > 0 && checkMatch(found[0], len(needles)) != nil {
+			found = nil
+		}
+	}
+	if len(found) == 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		found = m.scan(ctx, corpus, needles)
+		sortCandidates(found)
+	}
+	out := make([]Candidate, 0, n)
+	seen := make(map[Result]bool)
+	for _, c := range found {
+		if len(out) >= n && n > 0 {
+			break
+		}
+		if seen[c.result()] {
+			continue
+		}
+		seen[c.result()] = true
+		c.needles = len(needles)
+		out = append(out, c.candidate())
 	}
-	best := Result{}
-	bestMatches := 0
-	bestCoverage := 0.0
-	bestScore := 0
-	bestLen := 0
+	return out, nil
+}
+
+func (m *Matcher) scan(ctx context.Context, corpus QuranCorpus, needles []string) []matchCandidate {
+	var found []matchCandidate
 	startSurah := 1
 	endSurah := 114
 	if m.ExpectedSurah >= 1 && m.ExpectedSurah <= 114 {
@@ -33,7 +102,7 @@ func (m *Matcher) Identify(ctx context.Context, text string) (Result, error) {
 		endSurah = m.ExpectedSurah
 	}
 	for surah := startSurah; surah <= endSurah; surah++ {
-		ayahs, err := m.Corpus.FetchSurah(ctx, surah)
+		ayahs, err := corpus.FetchSurah(ctx, surah)
 		if err != nil {
 			continue
 		}
@@ -41,38 +110,73 @@ func (m *Matcher) Identify(ctx context.Context, text string) (Result, error) {
 		if !ok {
 			continue
 		}
-		if candidate.Matches > bestMatches ||
-			(candidate.Matches == bestMatches && candidate.Coverage > bestCoverage) ||
-			(candidate.Matches == bestMatches && candidate.Coverage == bestCoverage && candidate.Score > bestScore) ||
-			(candidate.Matches == bestMatches && candidate.Coverage == bestCoverage && candidate.Score == bestScore && candidate.Length > bestLen) {
-			bestMatches = candidate.Matches
-			bestCoverage = candidate.Coverage
-			bestScore = candidate.Score
-			bestLen = candidate.Length
-			best = Result{Surah: surah, StartAyah: candidate.StartAyah, EndAyah: candidate.EndAyah}
-		}
+		candidate.Surah = surah
+		found = append(found, candidate)
 	}
-	minMatches := minRequiredMatches(len(needles))
-	if bestMatches < minMatches || bestCoverage < minCoverage(len(needles)) {
-		return Result{}, errors.New("no reliable match found")
-	}
-	if bestScore == 0 {
-		return Result{}, errors.New("no match found")
-	}
-	return best, nil
+	return found
+}
+
+func sortCandidates(found []matchCandidate) {
+	sort.SliceStable(found, func(i, j int) bool { return betterCandidate(found[i], found[j]) })
 }
 
 type matchCandidate struct {
+	Surah     int
 	StartAyah int
 	EndAyah   int
 	Matches   int
 	Length    int
 	Score     int
 	Coverage  float64
+	needles   int
+}
+
+func (c matchCandidate) result() Result {
+	return Result{Surah: c.Surah, StartAyah: c.StartAyah, EndAyah: c.EndAyah}
+}
+
+func (c matchCandidate) candidate() Candidate {
+	confidence := 0.0
+	if c.needles > 0 {
+		// A perfect match scores 2 per transcribed token.
+		confidence = math.Max(0, math.Min(1, float64(c.Score)/float64(2*c.needles)))
+	}
+	return Candidate{
+		Result:     c.result(),
+		Matches:    c.Matches,
+		Length:     c.Length,
+		Score:      c.Score,
+		Coverage:   c.Coverage,
+		Confidence: confidence,
+		Reliable:   checkMatch(c, c.needles) == nil,
+		match:      c,
+	}
+}
+
+// betterCandidate ranks by matches, then coverage, score and length.
+func betterCandidate(c, best matchCandidate) bool {
+	return c.Matches > best.Matches ||
+		(c.Matches == best.Matches && c.Coverage > best.Coverage) ||
+		(c.Matches == best.Matches && c.Coverage == best.Coverage && c.Score > best.Score) ||
+		(c.Matches == best.Matches && c.Coverage == best.Coverage && c.Score == best.Score && c.Length > best.Length)
+}
+
+func checkMatch(best matchCandidate, needles int) error {
+	if best.Matches < minRequiredMatches(needles) || best.Coverage < minCoverage(needles) {
+		return errors.New("no reliable match found")
+	}
+	if best.Score == 0 {
+		return errors.New("no match found")
+	}
+	return nil
 }
 
 func matchSurah(needles []string, ayahs []Ayah) (matchCandidate, bool) {
 	tokens, tokenAyah := flattenAyahTokens(ayahs)
+	return matchTokens(needles, tokens, tokenAyah)
+}
+
+func matchTokens(needles []string, tokens []string, tokenAyah []int) (matchCandidate, bool) {
 	if len(tokens) == 0 || len(tokenAyah) == 0 {
 		return matchCandidate{}, false
 	}
@@ -267,6 +371,12 @@ func normalize(text string) string {
 	return strings.Join(strings.Fields(b.String()), " ")
 }
 
+// NormalizeWord folds a word the way the matcher compares tokens: no
+// diacritics or tatweel, one form of alef, ya and waw.
+func NormalizeWord(word string) string {
+	return normalize(word)
+}
+
 func normalizeTokens(text string) []string {
 	normalized := normalize(text)
 	if normalized == "" {
@@ -0,0 +1,178 @@
+package recognize
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"qgencodex/internal/align"
+)
+
+// maxSegmentWords caps a pause-delimited segment so a surah change without a
+// pause still gets its own identification.
+const maxSegmentWords = 60
+
+// Passage is one contiguous recited range in a longer recording.
+type Passage struct {
+	Result
+	Start time.Duration
+	End   time.Duration
+	// Words are the transcribed words of the passage, in recording time.
+	Words []align.WordTiming
+}
+
+// IdentifyPassages splits a timed transcript at pauses of at least minGap,
+// identifies each segment and joins consecutive segments that continue the
+// same surah. Segments that match nothing (du'a, khutbah speech) are dropped.
+func IdentifyPassages(ctx context.Context, words []align.WordTiming, matcher *Matcher, minGap time.Duration) ([]Passage, error) {
+	if matcher == nil {
+		return nil, errors.New("matcher is nil")
+	}
+	var passages []Passage
+	for _, segment := range splitAtPauses(words, minGap) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		text := joinWords(segment)
+		last := len(passages) - 1
+		// Short segments rarely identify on their own, so try the surah being
+		// recited first.
+		if last >= 0 {
+			scoped := *matcher
+			scoped.ExpectedSurah = passages[last].Surah
+			if result, err := scoped.Identify(ctx, text); err == nil && continues(passages[last].Result, result) {
+				passages[last].extend(result, segment)
+				continue
+			}
+		}
+		result, err := matcher.Identify(ctx, text)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			continue
+		}
+		if last >= 0 && continues(passages[last].Result, result) {
+			passages[last].extend(result, segment)
+			continue
+		}
+		passages = append(passages, Passage{
+			Result: result,
+			Start:  segment[0].Start,
+			End:    segment[len(segment)-1].End,
+			Words:  append([]align.WordTiming(nil), segment...),
+		})
+	}
+	if len(passages) == 0 {
+		return nil, errors.New("no passages identified")
+	}
+	return passages, nil
+}
+
+func (p *Passage) extend(result Result, segment []align.WordTiming) {
+	if result.StartAyah < p.StartAyah {
+		p.StartAyah = result.StartAyah
+	}
+	if result.EndAyah > p.EndAyah {
+		p.EndAyah = result.EndAyah
+	}
+	p.End = segment[len(segment)-1].End
+	p.Words = append(p.Words, segment...)
+}
+
+// continues reports whether next stays in the same surah and touches or
+// overlaps the current range (repeated ayahs included).
+func continues(current, next Result) bool {
+	return next.Surah == current.Surah &&
+		next.StartAyah <= current.EndAyah+1 &&
+		next.EndAyah >= current.StartAyah
+}
+
+func splitAtPauses(words []align.WordTiming, minGap time.Duration) [][]align.WordTiming {
+	var (
+		segments [][]align.WordTiming
+		current  []align.WordTiming
+	)
+	for _, w := range words {
+		if strings.TrimSpace(w.Word) == "" {
+			continue
+		}
+		if len(current) > 0 && (w.Start-current[len(current)-1].End >= minGap || len(current) >= maxSegmentWords) {
+			segments = append(segments, current)
+			current = nil
+		}
+		current = append(current, w)
+	}
+	if len(current) > 0 {
+		segments = append(segments, current)
+	}
+	return segments
+}
+
+func joinWords(words []align.WordTiming) string {
+	parts := make([]string, len(words))
+	for i, w := range words {
+		parts[i] = strings.TrimSpace(w.Word)
+	}
+	return strings.Join(parts, " ")
+}
+
+// AyahTime is where one ayah was recited in the recording.
+type AyahTime struct {
+	Surah int
+	Ayah  int
+	Start time.Duration
+	End   time.Duration
+}
+
+// AyahTimes aligns the ayahs of result to timed words and returns the span of
+// each ayah that received words.
+func (m *Matcher) AyahTimes(ctx context.Context, result Result, words []align.WordTiming) ([]AyahTime, error) {
+	corpus := m.Corpus
+	if m.Index != nil {
+		corpus = m.Index
+	}
+	if corpus == nil {
+		return nil, errors.New("corpus is nil")
+	}
+	ayahs, err := corpus.FetchSurah(ctx, result.Surah)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		expected []string
+		owner    []int
+	)
+	for i, a := range ayahs {
+		number := a.NumberInSurah
+		if number == 0 {
+			number = i + 1
+		}
+		if number < result.StartAyah || number > result.EndAyah {
+			continue
+		}
+		for _, w := range strings.Fields(a.Text) {
+			expected = append(expected, w)
+			owner = append(owner, number)
+		}
+	}
+	aligned, err := align.AlignWords(expected, words)
+	if err != nil {
+		return nil, err
+	}
+	var times []AyahTime
+	for i, wt := range aligned {
+		if i >= len(owner) {
+			break
+		}
+		if n := len(times) - 1; n >= 0 && times[n].Ayah == owner[i] {
+			if wt.End > times[n].End {
+				times[n].End = wt.End
+			}
+			continue
+		}
+		times = append(times, AyahTime{Surah: result.Surah, Ayah: owner[i], Start: wt.Start, End: wt.End})
+	}
+	return times, nil
+}
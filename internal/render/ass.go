@@ -21,14 +21,17 @@ type assOptions struct {
 
 func writeASSFile(dir, name string, opts assOptions) (string, error) {
 	path := filepath.Join(dir, name)
-	content := buildASSContent(opts)
+	content, err := buildASSContent(opts)
+	if err != nil {
+		return "", err
+	}
 	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
 		return "", err
 	}
 	return path, nil
 }
 
-func buildASSContent(opts assOptions) string {
+func buildASSContent(opts assOptions) (string, error) {
 	fontSize := opts.Config.Font.Size
 	if fontSize <= 0 {
 		fontSize = 64
@@ -77,68 +80,146 @@ func buildASSContent(opts assOptions) string {
 	header.WriteString("[Events]\n")
 	header.WriteString("Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n")
 
-	lines := buildASSLines(opts, fontSize)
-	return header.String() + strings.Join(lines, "")
+	lines, err := buildASSLines(opts, fontSize)
+	if err != nil {
+		return "", err
+	}
+	return header.String() + strings.Join(lines, ""), nil
 }
 
-func buildASSLines(opts assOptions, fontSize int) []string {
-	mode := strings.ToLower(opts.Mode)
+func buildASSLines(opts assOptions, fontSize int) ([]string, error) {
+	mode, err := config.ParseDisplayMode(opts.Mode)
+	if err != nil {
+		return nil, err
+	}
 	var lines []string
 	maxWidth := maxTextWidth(opts.Config, opts.Width)
-	switch mode {
-	case "sequential", "repeat", "sequential-repeat":
+	switch mode.Kind {
+	case config.ModeSequential:
 		for _, t := range opts.Timings {
-			text := assVerseText(opts.Config, maxWidth, t.Verse.Text, t.Verse.Translation, opts.IncludeTranslation, fontSize)
-			lines = append(lines, assDialogue(t.Start, t.End, assFadeOverride(opts.Config), text))
+			pages, size := fitVerse(t, opts.Config, opts.Width, opts.Height, opts.IncludeTranslation)
+			for _, page := range pages {
+				text := assVerseText(opts.Config, maxWidth, strings.Join(page.Lines, " "), page.Translation, page.Translation != "", size, nil)
+				if size != fontSize {
+					text = fmt.Sprintf("{\\fs%d}", size) + text
+				}
+				lines = append(lines, assDialogue(page.Start, page.End, assFadeOverride(opts.Config), text))
+			}
 		}
-	case "word-by-word", "word", "two-by-two", "two", "pair", "2x2", "repeat-2x2", "repeat-two-by-two", "repeat-pair":
-		for _, t := range opts.Timings {
-			if mode == "two-by-two" || mode == "two" || mode == "pair" || mode == "2x2" || mode == "repeat-2x2" || mode == "repeat-two-by-two" || mode == "repeat-pair" {
-				for i := 0; i < len(t.WordTimings); i += 2 {
-					first := t.WordTimings[i]
-					text := first.Word
-					end := first.End
-					if i+1 < len(t.WordTimings) {
-						second := t.WordTimings[i+1]
-						if second.Word != "" {
-							text = text + " " + second.Word
-						}
-						if second.End > end {
-							end = second.End
-						}
+	case config.ModeLineByLine:
+		for i, t := range opts.Timings {
+			for _, screen := range buildLineScreens(t, karaokeEnd(opts.Timings, i), maxWidth, fontSize, opts.Config.Font.File, opts.Config.LineByLine) {
+				if screen.End <= screen.Start {
+					continue
+				}
+				text := assVerseText(opts.Config, maxWidth, strings.Join(screen.Lines, " "), screen.Translation, opts.IncludeTranslation, fontSize, nil)
+				lines = append(lines, assDialogue(screen.Start, screen.End, assFadeOverride(opts.Config), text))
+			}
+		}
+	case config.ModeKaraoke:
+		for i, t := range opts.Timings {
+			steps := karaokeSteps(t, karaokeEnd(opts.Timings, i))
+			for j, step := range steps {
+				current := step.Word
+				mark := func(word int, text string) string {
+					if word != current {
+						return text
 					}
-					if opts.Config.Elongate {
-						text = elongateText(text, opts.Config.ElongateCount)
+					return assKaraokeWord(opts.Config, text) + assFontOverride(assArabicFontName(opts.Config))
+				}
+				text := assVerseText(opts.Config, maxWidth, t.Verse.Text, t.Verse.Translation, opts.IncludeTranslation, fontSize, mark)
+				lines = append(lines, assDialogue(step.Start, step.End, assStepFade(opts.Config, j == 0, j == len(steps)-1), text))
+			}
+		}
+	case config.ModePhrase:
+		for _, t := range opts.Timings {
+			for _, p := range buildPhrases(t, opts.Config.Phrase) {
+				text := assVerseText(opts.Config, maxWidth, p.Text, "", false, fontSize, nil)
+				lines = append(lines, assDialogue(p.Start, p.End, assFadeOverride(opts.Config), text))
+			}
+		}
+	case config.ModeProgressive:
+		for i, t := range opts.Timings {
+			end := karaokeEnd(opts.Timings, i)
+			words := len(strings.Fields(sanitizeText(t.Verse.Text)))
+			reveal := revealTimes(t, words, end)
+			first := true
+			for g, start := range reveal {
+				stop := end
+				if g+1 < len(reveal) {
+					stop = reveal[g+1]
+				}
+				if stop <= start {
+					continue
+				}
+				shown := g + 1
+				mark := func(word int, text string) string {
+					if word < shown {
+						return text
 					}
-					text = escapeASSText(text)
-					lines = append(lines, assDialogue(first.Start, end, assFadeOverride(opts.Config), text))
+					return "{\\alpha&HFF&}" + text + "{\\r}" + assFontOverride(assArabicFontName(opts.Config))
 				}
-			} else {
-				for _, w := range t.WordTimings {
-					text := w.Word
-					if opts.Config.Elongate {
-						text = elongateText(text, opts.Config.ElongateCount)
+				text := assVerseText(opts.Config, maxWidth, t.Verse.Text, t.Verse.Translation, opts.IncludeTranslation, fontSize, mark)
+				lines = append(lines, assDialogue(start, stop, assStepFade(opts.Config, first, stop == end), text))
+				first = false
+			}
+		}
+	case config.ModePrompter:
+		tp := newTeleprompter(opts.Timings, opts.Config, opts.Width, opts.Height, fontSize)
+		x := opts.Width / 2
+		for _, line := range tp.lines {
+			text := escapeASSText(sanitizeText(line.Text))
+			for _, span := range tp.spans(line) {
+				style := assPromptStyle(opts.Config, span.State)
+				breaks := tp.breaks(span)
+				for b := 0; b+1 < len(breaks); b++ {
+					from, to := tp.screenY(line, breaks[b]), tp.screenY(line, breaks[b+1])
+					pos := fmt.Sprintf("{\\an8\\move(%d,%.0f,%d,%.0f)}", x, from, x, to)
+					if from == to {
+						pos = fmt.Sprintf("{\\an8\\pos(%d,%.0f)}", x, from)
 					}
-					text = escapeASSText(text)
-					lines = append(lines, assDialogue(w.Start, w.End, assFadeOverride(opts.Config), text))
+					lines = append(lines, assDialogue(breaks[b], breaks[b+1], pos+style, text))
 				}
 			}
 		}
+	case config.ModeChunk:
+		for _, t := range opts.Timings {
+			for _, c := range displayChunks(t, mode, opts.Config, maxWidth, fontSize) {
+				text := c.Text
+				if opts.Config.Elongate {
+					text = elongateText(text, opts.Config.ElongateCount)
+				}
+				text = assColorOverride(c.Color) + escapeASSText(text)
+				lines = append(lines, assDialogue(c.Start, c.End, assFadeOverride(opts.Config), text))
+			}
+		}
 	}
-	return lines
+	return lines, nil
 }
 
 func assDialogue(start, end time.Duration, override, text string) string {
 	return fmt.Sprintf("Dialogue: 0,%s,%s,Default,,0,0,0,,%s%s\n", formatASSTime(start), formatASSTime(end), override, text)
 }
 
-func assVerseText(cfg config.VideoConfig, maxWidth int, arabic, translation string, includeTranslation bool, fontSize int) string {
+// assVerseText lays out an ayah with its translation. A non-nil mark restyles
+// the escaped ayah words by their index (karaoke, progressive reveal).
+func assVerseText(cfg config.VideoConfig, maxWidth int, arabic, translation string, includeTranslation bool, fontSize int, mark func(word int, text string) string) string {
 	arabicFont := assArabicFontName(cfg)
-	arabicLines := wrapText(arabic, maxWidth, fontSize)
+	arabicLines := wrapText(arabic, maxWidth, fontSize, cfg.Font.File)
 	arabicLines = maybeElongateLines(cfg, arabicLines, maxWidth, fontSize)
 	arabicParts := make([]string, 0, len(arabicLines))
+	word := 0
 	for _, line := range arabicLines {
-		arabicParts = append(arabicParts, assFontOverride(arabicFont)+escapeASSText(line))
+		if mark == nil {
+			arabicParts = append(arabicParts, assFontOverride(arabicFont)+escapeASSText(line))
+			continue
+		}
+		words := strings.Fields(line)
+		for i, w := range words {
+			words[i] = mark(word, escapeASSText(w))
+			word++
+		}
+		arabicParts = append(arabicParts, assFontOverride(arabicFont)+strings.Join(words, " "))
 	}
 	text := strings.Join(arabicParts, "\\N")
 	if includeTranslation && translation != "" {
@@ -154,7 +235,7 @@ func assVerseText(cfg config.VideoConfig, maxWidth int, arabic, translation stri
 		if translationFont == "" {
 			translationFont = "Helvetica"
 		}
-		translationLines := wrapText(translation, maxWidth, small)
+		translationLines := wrapText(translation, maxWidth, small, translationFontFile(cfg))
 		translationParts := make([]string, 0, len(translationLines))
 		for _, line := range translationLines {
 			translationParts = append(translationParts, assFontOverride(translationFont)+escapeASSText(line))
@@ -203,6 +284,14 @@ func assFontOverride(name string) string {
 	return "{\\fn" + name + "}"
 }
 
+// assColorOverride sets the primary color of one event; empty keeps the style color.
+func assColorOverride(color string) string {
+	if strings.TrimSpace(color) == "" {
+		return ""
+	}
+	return "{\\c" + assColor(color, "#FFFFFF") + "&}"
+}
+
 func assFadeOverride(cfg config.VideoConfig) string {
 	fi := cfg.FadeInMs
 	fo := cfg.FadeOutMs
@@ -282,3 +371,10 @@ func maxInt(a, b int) int {
 	}
 	return b
 }
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
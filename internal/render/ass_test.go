@@ -21,7 +21,7 @@ func TestBuildASSContent(t *testing.T) {
 		},
 		Config: cfg,
 	}
-	content := buildASSContent(opts)
+	content := assContent(t, opts)
 	if !strings.Contains(content, "[Events]") {
 		t.Fatalf("expected events section")
 	}
@@ -46,8 +46,50 @@ func TestASSFadeOverride(t *testing.T) {
 		},
 		Config: cfg,
 	}
-	content := buildASSContent(opts)
+	content := assContent(t, opts)
 	if !strings.Contains(content, "\\fad(100,200)") {
 		t.Fatalf("expected fade override in ASS content")
 	}
 }
+
+func TestASSWordColorOverride(t *testing.T) {
+	opts := assOptions{
+		Width:  1080,
+		Height: 1920,
+		Mode:   "word-by-word",
+		Timings: []Timing{
+			{
+				Verse: quran.Verse{Text: "بسم الله"},
+				End:   1 * time.Second,
+				WordTimings: []WordTiming{
+					{Word: "بسم", End: 500 * time.Millisecond},
+					{Word: "الله", Start: 500 * time.Millisecond, End: 1 * time.Second, Color: "#FF0000"},
+				},
+			},
+		},
+		Config: config.Default().Video,
+	}
+	content := assContent(t, opts)
+	if !strings.Contains(content, "{\\c&H000000FF&}الله") {
+		t.Fatalf("expected color override on the highlighted word:\n%s", content)
+	}
+	if strings.Contains(content, "\\c&H000000FF&}بسم") {
+		t.Fatalf("unexpected color override on a plain word")
+	}
+}
+
+func assContent(t *testing.T, opts assOptions) string {
+	t.Helper()
+	content, err := buildASSContent(opts)
+	if err != nil {
+		t.Fatalf("buildASSContent failed: %v", err)
+	}
+	return content
+}
+
+func TestASSRejectsUnknownMode(t *testing.T) {
+	opts := assOptions{Width: 1080, Height: 1920, Mode: "word-by-wrod", Config: config.Default().Video}
+	if _, err := buildASSContent(opts); err == nil {
+		t.Fatalf("expected an error for an unknown display mode")
+	}
+}
@@ -0,0 +1,348 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/image/font"
+
+	"qgencodex/internal/config"
+)
+
+// textCard is a rasterized overlay shown between Start and End.
+type textCard struct {
+	Path  string
+	Start time.Duration
+	End   time.Duration
+	// NoFadeIn and NoFadeOut join consecutive cards of one ayah (karaoke).
+	NoFadeIn  bool
+	NoFadeOut bool
+	// Y overrides the overlay y expression (teleprompter scroll).
+	Y string
+}
+
+// buildImageFilters shapes and rasterizes every ayah or word card in Go and
+// overlays the PNGs, so the output does not depend on ffmpeg's text shaping.
+func buildImageFilters(input RenderInput, width, height int) (string, error) {
+	if input.VideoConfig.Font.File == "" {
+		return "", fmt.Errorf("image renderer needs video.font.file (or an installed font.family)")
+	}
+	cards, err := writeTextCards(input, width, height)
+	if err != nil {
+		return "", err
+	}
+	chain := strings.Join(backgroundFilters(input, width, height), ",")
+	if len(cards) == 0 {
+		return chain + "[v]", nil
+	}
+	parts := []string{chain + "[base]"}
+	prev := "base"
+	y := overlayYExpr(input.VideoConfig)
+	fi, fo := input.VideoConfig.FadeInMs, input.VideoConfig.FadeOutMs
+	for i, card := range cards {
+		st, et := card.Start.Seconds(), card.End.Seconds()
+		src := fmt.Sprintf("movie='%s'", escapeValue(card.Path))
+		fi, fo := fi, fo
+		if card.NoFadeIn {
+			fi = 0
+		}
+		if card.NoFadeOut {
+			fo = 0
+		}
+		if fi > 0 || fo > 0 {
+			// A still image has one frame; loop it for the card's own window and
+			// shift it onto the output clock to fade.
+			src += fmt.Sprintf(",loop=loop=-1:size=1,setpts=N/30/TB,trim=duration=%.3f,setpts=PTS+%.3f/TB", et-st, st)
+			if fi > 0 {
+				src += fmt.Sprintf(",fade=t=in:st=%.3f:d=%.3f:alpha=1", st, float64(fi)/1000)
+			}
+			if fo > 0 {
+				src += fmt.Sprintf(",fade=t=out:st=%.3f:d=%.3f:alpha=1", maxFloat(st, et-float64(fo)/1000), float64(fo)/1000)
+			}
+		}
+		out := fmt.Sprintf("ov%d", i)
+		if i == len(cards)-1 {
+			out = "v"
+		}
+		cardY := y
+		if card.Y != "" {
+			cardY = "'" + card.Y + "'"
+		}
+		parts = append(parts,
+			fmt.Sprintf("%s[card%d]", src, i),
+			fmt.Sprintf("[%s][card%d]overlay=x=(W-w)/2:y=%s:eof_action=pass:enable='between(t,%.3f,%.3f)'[%s]", prev, i, cardY, st, et, out),
+		)
+		prev = out
+	}
+	return strings.Join(parts, ";"), nil
+}
+
+func writeTextCards(input RenderInput, width, height int) ([]textCard, error) {
+	cfg := input.VideoConfig
+	fontSize := cfg.Font.Size
+	if fontSize <= 0 {
+		fontSize = 64
+	}
+	arabic, err := newFace(cfg.Font.File, fontSize)
+	if err != nil {
+		return nil, err
+	}
+	maxWidth := maxTextWidth(cfg, width)
+	mainColor := parseHexColor(cfg.Font.Color, color.White)
+	var cards []textCard
+	add := func(name string, start, end time.Duration, lines []cardLine) error {
+		if end <= start || len(lines) == 0 {
+			return nil
+		}
+		path := filepath.Join(input.TempDir, name)
+		if err := writePNG(path, drawCard(cfg, lines)); err != nil {
+			return err
+		}
+		cards = append(cards, textCard{Path: path, Start: start, End: end})
+		return nil
+	}
+	// verseLines lays out wrapped ayah lines at size with a translation and
+	// the reference, as in sequential mode.
+	verseLines := func(face font.Face, size int, extra cardFaces, t Timing, arabicLines []string, translation string) []cardLine {
+		var lines []cardLine
+		for _, line := range maybeElongateLines(cfg, arabicLines, maxWidth, size) {
+			lines = append(lines, cardLine{face: face, text: shapeArabic(line), color: mainColor})
+		}
+		if input.IncludeTranslation && translation != "" {
+			for i, line := range wrapText(translation, maxWidth, size/2, translationFontFile(cfg)) {
+				cl := cardLine{face: extra.translation, text: plainClusters(line), color: color.White}
+				if i == 0 {
+					cl.gapBefore = extra.translationGap
+				}
+				lines = append(lines, cl)
+			}
+		}
+		if cfg.Reference.Enabled {
+			ref := fmt.Sprintf("%s • %d", t.Verse.SurahMeta.EnglishName, t.Verse.NumberInSurah)
+			lines = append(lines, cardLine{face: extra.reference, text: plainClusters(ref), color: parseHexColor(cfg.Reference.Color, color.White)})
+		}
+		return lines
+	}
+	mode, err := config.ParseDisplayMode(input.Mode)
+	if err != nil {
+		return nil, err
+	}
+	switch mode.Kind {
+	case config.ModeSequential:
+		// Faces by size, as auto-fit may shrink some ayahs.
+		faces := map[int]font.Face{fontSize: arabic}
+		extras := map[int]cardFaces{}
+		for idx, t := range input.Timings {
+			pages, size := fitVerse(t, cfg, width, height, input.IncludeTranslation)
+			if faces[size] == nil {
+				if faces[size], err = newFace(cfg.Font.File, size); err != nil {
+					return nil, err
+				}
+			}
+			extra, ok := extras[size]
+			if !ok {
+				if extra, err = newCardFaces(input, size); err != nil {
+					return nil, err
+				}
+				extras[size] = extra
+			}
+			for pidx, page := range pages {
+				name := fmt.Sprintf("card_ayah_%d.png", idx)
+				if pidx > 0 {
+					name = fmt.Sprintf("card_ayah_%d_page_%d.png", idx, pidx)
+				}
+				lines := verseLines(faces[size], size, extra, t, page.Lines, page.Translation)
+				if err := add(name, page.Start, page.End, lines); err != nil {
+					return nil, err
+				}
+			}
+		}
+	case config.ModeLineByLine:
+		extra, err := newCardFaces(input, fontSize)
+		if err != nil {
+			return nil, err
+		}
+		for idx, t := range input.Timings {
+			for sidx, screen := range buildLineScreens(t, karaokeEnd(input.Timings, idx), maxWidth, fontSize, cfg.Font.File, cfg.LineByLine) {
+				lines := verseLines(arabic, fontSize, extra, t, screen.Lines, screen.Translation)
+				if err := add(fmt.Sprintf("card_ayah_%d_lines_%d.png", idx, sidx), screen.Start, screen.End, lines); err != nil {
+					return nil, err
+				}
+			}
+		}
+	case config.ModeKaraoke:
+		for idx, t := range input.Timings {
+			arabicLines := wrapText(t.Verse.Text, maxWidth, fontSize, cfg.Font.File)
+			arabicLines = maybeElongateLines(cfg, arabicLines, maxWidth, fontSize)
+			steps := karaokeSteps(t, karaokeEnd(input.Timings, idx))
+			for sidx, step := range steps {
+				var lines []cardLine
+				word := step.Word
+				for _, line := range arabicLines {
+					cl := cardLine{face: arabic, text: shapeArabic(line), color: mainColor}
+					count := len(strings.Fields(line))
+					if word >= 0 && word < count {
+						cl.highlight = wordSpan(cl.text, word)
+					}
+					word -= count
+					lines = append(lines, cl)
+				}
+				if err := add(fmt.Sprintf("card_ayah_%d_step_%d.png", idx, sidx), step.Start, step.End, lines); err != nil {
+					return nil, err
+				}
+				cards[len(cards)-1].NoFadeIn = sidx > 0
+				cards[len(cards)-1].NoFadeOut = sidx < len(steps)-1
+			}
+		}
+	case config.ModePhrase:
+		for idx, t := range input.Timings {
+			for pidx, p := range buildPhrases(t, cfg.Phrase) {
+				arabicLines := wrapText(p.Text, maxWidth, fontSize, cfg.Font.File)
+				arabicLines = maybeElongateLines(cfg, arabicLines, maxWidth, fontSize)
+				var lines []cardLine
+				for _, line := range arabicLines {
+					lines = append(lines, cardLine{face: arabic, text: shapeArabic(line), color: mainColor})
+				}
+				if err := add(fmt.Sprintf("card_ayah_%d_phrase_%d.png", idx, pidx), p.Start, p.End, lines); err != nil {
+					return nil, err
+				}
+			}
+		}
+	case config.ModeProgressive:
+		for idx, t := range input.Timings {
+			arabicLines := wrapText(t.Verse.Text, maxWidth, fontSize, cfg.Font.File)
+			arabicLines = maybeElongateLines(cfg, arabicLines, maxWidth, fontSize)
+			end := karaokeEnd(input.Timings, idx)
+			total := 0
+			for _, line := range arabicLines {
+				total += len(strings.Fields(line))
+			}
+			reveal := revealTimes(t, total, end)
+			first := true
+			for g, start := range reveal {
+				stop := end
+				if g+1 < len(reveal) {
+					stop = reveal[g+1]
+				}
+				if stop <= start {
+					continue
+				}
+				var lines []cardLine
+				shown := g + 1
+				for _, line := range arabicLines {
+					cl := cardLine{face: arabic, text: shapeArabic(line), color: mainColor}
+					count := len(strings.Fields(line))
+					if shown <= 0 {
+						cl.hidden = [2]int{0, len(cl.text)}
+					} else if shown < count {
+						// Later words are on the left in visual order.
+						cl.hidden = [2]int{0, wordSpan(cl.text, shown-1)[0]}
+					}
+					shown -= count
+					lines = append(lines, cl)
+				}
+				if err := add(fmt.Sprintf("card_ayah_%d_reveal_%d.png", idx, g), start, stop, lines); err != nil {
+					return nil, err
+				}
+				cards[len(cards)-1].NoFadeIn = !first
+				cards[len(cards)-1].NoFadeOut = stop != end
+				first = false
+			}
+		}
+	case config.ModePrompter:
+		tp := newTeleprompter(input.Timings, cfg, width, height, fontSize)
+		highlight := parseHexColor(cfg.Teleprompter.HighlightColor, mainColor)
+		for lidx, line := range tp.lines {
+			for _, span := range tp.spans(line) {
+				cl := cardLine{face: arabic, text: shapeArabic(sanitizeText(line.Text)), color: mainColor}
+				if span.State == promptCurrent {
+					cl.color = highlight
+				}
+				img := drawCard(cfg, []cardLine{cl})
+				if span.State == promptPast {
+					dimImage(img, cfg.Teleprompter.DimOpacity)
+				}
+				path := filepath.Join(input.TempDir, fmt.Sprintf("card_prompter_%d_%d.png", lidx, span.State))
+				if err := writePNG(path, img); err != nil {
+					return nil, err
+				}
+				cards = append(cards, textCard{Path: path, Start: span.Start, End: span.End, NoFadeIn: true, NoFadeOut: true, Y: tp.yExpr(line, span)})
+			}
+		}
+	case config.ModeChunk:
+		for idx, t := range input.Timings {
+			for cidx, c := range displayChunks(t, mode, cfg, maxWidth, fontSize) {
+				text := sanitizeText(c.Text)
+				if cfg.Elongate {
+					text = elongateText(text, cfg.ElongateCount)
+				}
+				if strings.TrimSpace(text) == "" {
+					continue
+				}
+				line := cardLine{face: arabic, text: shapeArabic(text), color: parseHexColor(c.Color, mainColor)}
+				if err := add(fmt.Sprintf("card_ayah_%d_chunk_%d.png", idx, cidx), c.Start, c.End, []cardLine{line}); err != nil {
+					return nil, err
+				}
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported display mode: %s", input.Mode)
+	}
+	return cards, nil
+}
+
+// cardFaces are the secondary faces of ayah cards.
+type cardFaces struct {
+	translation    font.Face
+	reference      font.Face
+	translationGap int
+}
+
+// newCardFaces loads the translation font (falling back to the bundled Latin
+// font) and the reference face.
+func newCardFaces(input RenderInput, fontSize int) (cardFaces, error) {
+	cfg := input.VideoConfig
+	small := fontSize / 2
+	translationFile := ""
+	if family := strings.TrimSpace(cfg.TranslationFont); family != "" {
+		translationFile = ResolveFontFile(family)
+	}
+	translation, err := newFace(translationFile, small)
+	if err != nil {
+		return cardFaces{}, err
+	}
+	refSize := cfg.Reference.Size
+	if refSize <= 0 {
+		refSize = 28
+	}
+	reference, err := newFace(translationFile, refSize)
+	if err != nil {
+		return cardFaces{}, err
+	}
+	gap := cfg.TranslationSpacing
+	if gap == 0 {
+		gap = 24
+	}
+	return cardFaces{translation: translation, reference: reference, translationGap: gap}, nil
+}
+
+func overlayYExpr(cfg config.VideoConfig) string {
+	switch strings.ToLower(cfg.TextPosition) {
+	case "lower-third", "lower", "bottom":
+		return "(H*0.65)-(h/2)"
+	case "upper", "top":
+		return "(H*0.25)-(h/2)"
+	default:
+		return "(H-h)/2"
+	}
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
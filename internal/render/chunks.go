@@ -0,0 +1,126 @@
+package render
+
+import (
+	"strings"
+	"time"
+
+	"qgencodex/internal/config"
+)
+
+// wordChunk is a run of words shown together.
+type wordChunk struct {
+	Text  string
+	Start time.Duration
+	End   time.Duration
+	// Color overrides the font color; only single words carry one.
+	Color string
+}
+
+// displayChunks splits an ayah for a chunk mode: a fixed number of words, or
+// for chunk:auto as many as fit the target duration and one line.
+func displayChunks(t Timing, mode config.DisplayMode, cfg config.VideoConfig, maxWidth, fontSize int) []wordChunk {
+	if mode.Chunk > 0 {
+		return buildWordChunks(t, mode.Chunk)
+	}
+	limit := float64(maxWidth) * wrapThreshold
+	return buildAutoChunks(t, cfg.Chunk, func(text string) bool {
+		return textWidth(cfg.Font.File, text, fontSize) <= limit
+	})
+}
+
+// buildWordChunks groups the word timings n at a time. Single words keep
+// their timing and color as they are; larger chunks fall back to an even
+// split when the timings are unusable.
+func buildWordChunks(t Timing, n int) []wordChunk {
+	if n == 1 {
+		out := make([]wordChunk, 0, len(t.WordTimings))
+		for _, w := range t.WordTimings {
+			out = append(out, wordChunk{Text: w.Word, Start: w.Start, End: w.End, Color: w.Color})
+		}
+		return out
+	}
+	if len(t.WordTimings) == 0 {
+		return buildEvenChunksFromText(t.Verse.Text, t.Start, t.End, n)
+	}
+	words := make([]string, len(t.WordTimings))
+	for i, w := range t.WordTimings {
+		words[i] = w.Word
+	}
+	chunks := make([]wordChunk, 0, (len(words)+n-1)/n)
+	for i := 0; i < len(t.WordTimings); i += n {
+		chunk := joinChunk(t.WordTimings[i:minInt(i+n, len(t.WordTimings))])
+		if chunk.End <= chunk.Start || chunk.Text == "" {
+			return buildEvenChunks(words, t.Start, t.End, n)
+		}
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// buildAutoChunks grows each chunk word by word while it lasts less than
+// cfg.TargetMs, stays under cfg.MaxWords and fits reports that the text fits
+// on one line. Every chunk has at least one word.
+func buildAutoChunks(t Timing, cfg config.ChunkConfig, fits func(text string) bool) []wordChunk {
+	words := t.WordTimings
+	if !validWordTimings(words) {
+		words = SplitWordTimings(strings.Fields(t.Verse.Text), t.Start, t.End)
+	}
+	target := time.Duration(cfg.TargetMs) * time.Millisecond
+	var chunks []wordChunk
+	for i := 0; i < len(words); {
+		j := i + 1
+		for j < len(words) && (cfg.MaxWords <= 0 || j-i < cfg.MaxWords) && words[j-1].End-words[i].Start < target {
+			if !fits(joinChunk(words[i : j+1]).Text) {
+				break
+			}
+			j++
+		}
+		chunks = append(chunks, joinChunk(words[i:j]))
+		i = j
+	}
+	return chunks
+}
+
+// joinChunk shows words together from the first start to the latest end.
+func joinChunk(words []WordTiming) wordChunk {
+	chunk := wordChunk{Start: words[0].Start, End: words[0].End}
+	texts := make([]string, 0, len(words))
+	for _, w := range words {
+		if text := strings.TrimSpace(w.Word); text != "" {
+			texts = append(texts, text)
+		}
+		if w.End > chunk.End {
+			chunk.End = w.End
+		}
+	}
+	chunk.Text = strings.Join(texts, " ")
+	return chunk
+}
+
+func validWordTimings(words []WordTiming) bool {
+	if len(words) == 0 {
+		return false
+	}
+	for _, w := range words {
+		if w.End <= w.Start || strings.TrimSpace(w.Word) == "" {
+			return false
+		}
+	}
+	return true
+}
+
+func buildEvenChunksFromText(text string, start, end time.Duration, n int) []wordChunk {
+	return buildEvenChunks(strings.Fields(text), start, end, n)
+}
+
+func buildEvenChunks(words []string, start, end time.Duration, n int) []wordChunk {
+	if len(words) == 0 || end <= start {
+		return nil
+	}
+	timings := SplitWordTimings(words, start, end)
+	chunks := make([]wordChunk, 0, (len(timings)+n-1)/n)
+	for i := 0; i < len(timings); i += n {
+		chunks = append(chunks, joinChunk(timings[i:minInt(i+n, len(timings))]))
+	}
+	return chunks
+}
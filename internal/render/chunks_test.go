@@ -0,0 +1,54 @@
+package render
+
+import (
+	"testing"
+	"time"
+
+	"qgencodex/internal/config"
+	"qgencodex/internal/quran"
+)
+
+func TestBuildWordChunks(t *testing.T) {
+	timing := Timing{WordTimings: timedWords("a", "b", "c", "d", "e")}
+	got := phraseTexts(buildWordChunks(timing, 3))
+	if len(got) != 2 || got[0] != "a b c" || got[1] != "d e" {
+		t.Fatalf("expected chunks of three, got %q", got)
+	}
+	timing.WordTimings[1].Color = "#FF0000"
+	if words := buildWordChunks(timing, 1); len(words) != 5 || words[1].Color != "#FF0000" {
+		t.Fatalf("expected single words to keep their color, got %+v", words)
+	}
+	even := buildWordChunks(Timing{Verse: quran.Verse{Text: "a b c d"}, End: 4 * time.Second}, 2)
+	if len(even) != 2 || even[1].End != 4*time.Second {
+		t.Fatalf("expected an even split without word timings, got %+v", even)
+	}
+}
+
+func TestBuildAutoChunks(t *testing.T) {
+	words := timedWords("a", "b", "c", "d", "e", "f")
+	// "c" is a long word: 3s on its own.
+	for i := 2; i < len(words); i++ {
+		if i > 2 {
+			words[i].Start += 2 * time.Second
+		}
+		words[i].End += 2 * time.Second
+	}
+	fits := func(text string) bool { return len(text) <= 5 }
+	got := phraseTexts(buildAutoChunks(Timing{WordTimings: words}, config.ChunkConfig{TargetMs: 2500, MaxWords: 4}, fits))
+	// Words join while the chunk is under 2.5s, so the long "c" closes the
+	// first chunk; a fourth word would not fit.
+	want := []string{"a b c", "d e f"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	got = phraseTexts(buildAutoChunks(Timing{WordTimings: words}, config.ChunkConfig{TargetMs: 1500}, fits))
+	want = []string{"a b", "c", "d e", "f"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %q, got %q", want, got)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package render
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"qgencodex/internal/utils"
+)
+
+// SourceEstimated marks word timings from the letter-weighted split rather than an aligner.
+const SourceEstimated = "estimated"
+
+// lowWordConfidence is the level below which words are listed in the report.
+const lowWordConfidence = 0.5
+
+// AlignmentReport summarises word timing provenance and confidence for a render.
+type AlignmentReport struct {
+	Score         float64             `json:"score"`
+	Words         int                 `json:"words"`
+	Sources       map[string]int      `json:"sources"`
+	Ayahs         []AyahAlignment     `json:"ayahs"`
+	LowConfidence []LowConfidenceWord `json:"low_confidence"`
+}
+
+type AyahAlignment struct {
+	Surah int     `json:"surah"`
+	Ayah  int     `json:"ayah"`
+	Words int     `json:"words"`
+	Score float64 `json:"score"`
+}
+
+type LowConfidenceWord struct {
+	Surah       int     `json:"surah"`
+	Ayah        int     `json:"ayah"`
+	Word        string  `json:"word"`
+	StartSec    float64 `json:"start_sec"`
+	EndSec      float64 `json:"end_sec"`
+	Source      string  `json:"source"`
+	Confidence  float64 `json:"confidence"`
+	Probability float64 `json:"probability,omitempty"`
+}
+
+// HasAlignedWords reports whether any word timing came from an aligner.
+func HasAlignedWords(timings []Timing) bool {
+	for _, t := range timings {
+		for _, wt := range t.WordTimings {
+			if wt.Source != "" && wt.Source != SourceEstimated {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// BuildAlignmentReport scores every word timing. Consecutive timings of the
+// same ayah (pause-sensitive splits) share one ayah entry.
+func BuildAlignmentReport(timings []Timing) AlignmentReport {
+	report := AlignmentReport{Sources: map[string]int{}}
+	total := 0.0
+	for _, t := range timings {
+		surah := t.Verse.SurahMeta.Number
+		ayah := t.Verse.NumberInSurah
+		if len(t.WordTimings) == 0 {
+			continue
+		}
+		n := len(report.Ayahs)
+		if n == 0 || report.Ayahs[n-1].Surah != surah || report.Ayahs[n-1].Ayah != ayah {
+			report.Ayahs = append(report.Ayahs, AyahAlignment{Surah: surah, Ayah: ayah})
+			n++
+		}
+		entry := &report.Ayahs[n-1]
+		for _, wt := range t.WordTimings {
+			source := wt.Source
+			if source == "" {
+				source = SourceEstimated
+			}
+			report.Sources[source]++
+			report.Words++
+			total += wt.Confidence
+			entry.Score = (entry.Score*float64(entry.Words) + wt.Confidence) / float64(entry.Words+1)
+			entry.Words++
+			if wt.Confidence < lowWordConfidence {
+				report.LowConfidence = append(report.LowConfidence, LowConfidenceWord{
+					Surah:       surah,
+					Ayah:        ayah,
+					Word:        wt.Word,
+					StartSec:    wt.Start.Seconds(),
+					EndSec:      wt.End.Seconds(),
+					Source:      source,
+					Confidence:  wt.Confidence,
+					Probability: wt.Probability,
+				})
+			}
+		}
+	}
+	if report.Words > 0 {
+		report.Score = total / float64(report.Words)
+	}
+	return report
+}
+
+// WriteAlignmentReport writes report as indented JSON.
+func WriteAlignmentReport(path string, report AlignmentReport) error {
+	if err := utils.EnsureDir(filepath.Dir(path)); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,40 @@
+package render
+
+import (
+	"testing"
+	"time"
+
+	"qgencodex/internal/quran"
+)
+
+func TestBuildAlignmentReport(t *testing.T) {
+	verse := quran.Verse{NumberInSurah: 2, SurahMeta: quran.SurahMeta{Number: 112}}
+	timings := []Timing{
+		{Verse: verse, WordTimings: []WordTiming{
+			{Word: "الله", Source: "matched", Confidence: 0.9},
+			{Word: "الصمد", Start: time.Second, End: 2 * time.Second, Source: "interpolated"},
+		}},
+		{Verse: verse, WordTimings: []WordTiming{
+			{Word: "x", Source: SourceEstimated},
+		}},
+	}
+	if !HasAlignedWords(timings) {
+		t.Fatalf("expected aligned words")
+	}
+	report := BuildAlignmentReport(timings)
+	if report.Words != 3 || len(report.Ayahs) != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if report.Score < 0.29 || report.Score > 0.31 {
+		t.Fatalf("expected score 0.3, got %v", report.Score)
+	}
+	if len(report.LowConfidence) != 2 || report.LowConfidence[0].Word != "الصمد" || report.LowConfidence[0].EndSec != 2 {
+		t.Fatalf("unexpected low-confidence words: %+v", report.LowConfidence)
+	}
+	if report.Sources["interpolated"] != 1 || report.Sources[SourceEstimated] != 1 {
+		t.Fatalf("unexpected source counts: %+v", report.Sources)
+	}
+	if HasAlignedWords([]Timing{{WordTimings: SplitWordTimings([]string{"a", "b"}, 0, time.Second)}}) {
+		t.Fatalf("estimated timings should not count as aligned")
+	}
+}
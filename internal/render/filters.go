@@ -9,6 +9,10 @@ import (
 
 // DrawtextArgs builds a drawtext filter for a text file.
 func DrawtextArgs(textFile string, enable string, cfg config.VideoConfig, fontSize int, color string, yExpr string, alphaExpr string) string {
+	return drawtextArgsAt(textFile, enable, cfg, fontSize, color, "(w-text_w)/2", yExpr, alphaExpr)
+}
+
+func drawtextArgsAt(textFile string, enable string, cfg config.VideoConfig, fontSize int, color string, xExpr string, yExpr string, alphaExpr string) string {
 	font := ""
 	if cfg.Font.File != "" {
 		font = fmt.Sprintf("fontfile='%s'", escapeValue(cfg.Font.File))
@@ -62,7 +66,7 @@ func DrawtextArgs(textFile string, enable string, cfg config.VideoConfig, fontSi
 		fmt.Sprintf("shadowy=%d", cfg.Font.ShadowY),
 		strings.Join(boxArgs, ":"),
 		fmt.Sprintf("line_spacing=%d", lineSpacing),
-		"x=(w-text_w)/2",
+		fmt.Sprintf("x=%s", xExpr),
 		// "text_shaping=1",
 		fmt.Sprintf("y=%s", yExpr),
 	}
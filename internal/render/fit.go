@@ -0,0 +1,139 @@
+package render
+
+import (
+	"strings"
+
+	"qgencodex/internal/config"
+)
+
+// fitVerse lays out an ayah card so the ayah and its translation fit between
+// the top and bottom margins. In shrink mode the font steps down to
+// video.fit.min_size; whatever still overflows, or any overflow in page mode,
+// is split into pages timed like line-by-line screens. It returns the pages
+// and the font size they use.
+func fitVerse(t Timing, cfg config.VideoConfig, width, height int, includeTranslation bool) ([]lineScreen, int) {
+	fontSize := cfg.Font.Size
+	if fontSize <= 0 {
+		fontSize = 64
+	}
+	maxWidth := maxTextWidth(cfg, width)
+	translation := ""
+	if includeTranslation {
+		translation = t.Verse.Translation
+	}
+	whole := []lineScreen{{Lines: wrapText(t.Verse.Text, maxWidth, fontSize, cfg.Font.File), Translation: translation, Start: t.Start, End: t.End}}
+	mode := strings.ToLower(cfg.Fit.Mode)
+	if mode == "off" {
+		return whole, fontSize
+	}
+	safe := safeHeight(cfg, height)
+	size := fontSize
+	if mode != "page" {
+		minSize := cfg.Fit.MinSize
+		if minSize <= 0 || minSize > fontSize {
+			minSize = fontSize
+		}
+		for ; size > minSize; size -= 2 {
+			if lines := wrapText(t.Verse.Text, maxWidth, size, cfg.Font.File); blockHeight(cfg, maxWidth, lines, translation, size) <= safe {
+				whole[0].Lines = lines
+				return whole, size
+			}
+		}
+		size = minSize
+	}
+	lines := wrapText(t.Verse.Text, maxWidth, size, cfg.Font.File)
+	if blockHeight(cfg, maxWidth, lines, translation, size) <= safe {
+		whole[0].Lines = lines
+		return whole, size
+	}
+	// The most lines per page that keep every page, with its share of the
+	// translation, inside the safe area.
+	perPage := maxInt(len(lines)-1, 1)
+	pages := buildLineScreens(t, t.End, maxWidth, size, cfg.Font.File, config.LineConfig{Lines: perPage, Translation: "split"})
+	for perPage > 1 && !pagesFit(cfg, maxWidth, pages, includeTranslation, size, safe) {
+		perPage--
+		pages = buildLineScreens(t, t.End, maxWidth, size, cfg.Font.File, config.LineConfig{Lines: perPage, Translation: "split"})
+	}
+	// A page with no time of its own (its words share a timestamp with the
+	// next page's) is shown with its neighbour rather than dropped.
+	var out []lineScreen
+	var carry lineScreen
+	for _, page := range pages {
+		if !includeTranslation {
+			page.Translation = ""
+		}
+		switch {
+		case page.End <= page.Start && len(out) > 0:
+			out[len(out)-1] = mergePage(out[len(out)-1], page)
+		case page.End <= page.Start:
+			carry = mergePage(carry, page)
+		default:
+			if len(carry.Lines) > 0 {
+				merged := mergePage(carry, page)
+				merged.Start, merged.End = page.Start, page.End
+				page, carry = merged, lineScreen{}
+			}
+			out = append(out, page)
+		}
+	}
+	if len(out) == 0 {
+		carry.Start, carry.End = t.Start, t.End
+		out = append(out, carry)
+	}
+	return out, size
+}
+
+// mergePage appends next's lines and translation to page, keeping page's
+// timing.
+func mergePage(page, next lineScreen) lineScreen {
+	page.Lines = append(append([]string(nil), page.Lines...), next.Lines...)
+	page.Translation = strings.TrimSpace(page.Translation + " " + next.Translation)
+	return page
+}
+
+func pagesFit(cfg config.VideoConfig, maxWidth int, pages []lineScreen, includeTranslation bool, fontSize, safe int) bool {
+	for _, page := range pages {
+		translation := page.Translation
+		if !includeTranslation {
+			translation = ""
+		}
+		if blockHeight(cfg, maxWidth, page.Lines, translation, fontSize) > safe {
+			return false
+		}
+	}
+	return true
+}
+
+// blockHeight estimates the height of wrapped ayah lines and the translation
+// below them.
+func blockHeight(cfg config.VideoConfig, maxWidth int, lines []string, translation string, fontSize int) int {
+	height := len(lines) * lineHeight(cfg, fontSize)
+	if translation != "" {
+		small := fontSize / 2
+		spacing := cfg.TranslationSpacing
+		if spacing == 0 {
+			spacing = 24
+		}
+		height += spacing + len(wrapText(translation, maxWidth, small, translationFontFile(cfg)))*lineHeight(cfg, small)
+	}
+	return height
+}
+
+// lineHeight is the height of one wrapped line; Arabic marks above and below
+// the letters need about half the font size again.
+func lineHeight(cfg config.VideoConfig, fontSize int) int {
+	spacing := cfg.LineSpacing
+	if spacing == 0 {
+		spacing = 10
+	}
+	return fontSize*3/2 + spacing
+}
+
+// safeHeight is the frame height between the top and bottom margins.
+func safeHeight(cfg config.VideoConfig, height int) int {
+	safe := height - defaultIfZero(cfg.Margins.Top, 140) - defaultIfZero(cfg.Margins.Bottom, 200)
+	if safe <= 0 {
+		safe = height * 3 / 4
+	}
+	return safe
+}
@@ -0,0 +1,102 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"qgencodex/internal/config"
+	"qgencodex/internal/quran"
+)
+
+// longTiming is forty one-second words: fourteen lines at 64px on a 1080px
+// wide frame.
+func longTiming() Timing {
+	words := strings.Fields(strings.Repeat("aaaa bbbb ", 20))
+	timing := Timing{Verse: quran.Verse{Text: strings.Join(words, " ")}, End: 40 * time.Second}
+	timing.WordTimings = timedWords(words...)
+	return timing
+}
+
+func TestFitVerseShrinks(t *testing.T) {
+	cfg := config.Default().Video
+	// 1200 - 140 - 200 leaves 860px: 10 lines at 50px.
+	pages, size := fitVerse(longTiming(), cfg, 1080, 1200, false)
+	if len(pages) != 1 || size != 50 {
+		t.Fatalf("expected one card at 50px, got %d pages at %dpx", len(pages), size)
+	}
+	cfg.Fit.Mode = "off"
+	if pages, size := fitVerse(longTiming(), cfg, 1080, 1200, false); len(pages) != 1 || size != 64 || len(pages[0].Lines) != 14 {
+		t.Fatalf("expected fit off to keep 64px, got %d pages at %dpx", len(pages), size)
+	}
+}
+
+func TestFitVersePages(t *testing.T) {
+	cfg := config.Default().Video
+	cfg.Fit.Mode = "page"
+	pages, size := fitVerse(longTiming(), cfg, 1080, 1200, false)
+	if size != 64 || len(pages) != 2 || len(pages[0].Lines) != 8 {
+		t.Fatalf("expected two pages of at most eight lines at 64px, got %d pages at %dpx", len(pages), size)
+	}
+	if pages[0].Start != 0 || pages[0].End != pages[1].Start || pages[1].End != 40*time.Second {
+		t.Fatalf("expected pages timed back to back across the ayah, got %+v", pages)
+	}
+	for _, page := range pages {
+		if h := blockHeight(cfg, maxTextWidth(cfg, 1080), page.Lines, "", size); h > safeHeight(cfg, 1200) {
+			t.Fatalf("expected every page inside the margins, got %dpx", h)
+		}
+	}
+}
+
+func TestDrawtextFitPages(t *testing.T) {
+	cfg := config.Default().Video
+	cfg.Fit.Mode = "page"
+	cfg.Reference.Enabled = false
+	dir := t.TempDir()
+	input := RenderInput{Timings: []Timing{longTiming()}, TempDir: dir, Mode: "sequential", VideoConfig: cfg}
+	filters, err := buildDrawtextFilters(input, 1080, 1200)
+	if err != nil {
+		t.Fatalf("buildDrawtextFilters failed: %v", err)
+	}
+	if strings.Count(filters, "drawtext=") != 2 {
+		t.Fatalf("expected one drawtext per page: %s", filters)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ayah_0_page_1.txt")); err != nil {
+		t.Fatalf("expected the second page text file: %v", err)
+	}
+}
+
+func TestDrawtextShortAyahKeepsPlacement(t *testing.T) {
+	cfg := config.Default().Video
+	cfg.Reference.Enabled = false
+	timing := Timing{Verse: quran.Verse{Text: "aaaa bbbb", Translation: "short"}, End: 2 * time.Second}
+	input := RenderInput{Timings: []Timing{timing}, TempDir: t.TempDir(), Mode: "sequential", VideoConfig: cfg, IncludeTranslation: true}
+	filters, err := buildDrawtextFilters(input, 1080, 1920)
+	if err != nil {
+		t.Fatalf("buildDrawtextFilters failed: %v", err)
+	}
+	if !strings.Contains(filters, "y=(h-text_h)/2+88") {
+		t.Fatalf("expected the translation at the old offset below the ayah: %s", filters)
+	}
+}
+
+func TestFitVerseMergesUntimedPages(t *testing.T) {
+	cfg := config.Default().Video
+	cfg.Fit.Mode = "page"
+	timing := longTiming()
+	// The second page's words all start with the last word of the ayah.
+	for i := 16; i < len(timing.WordTimings); i++ {
+		timing.WordTimings[i].Start = timing.End
+		timing.WordTimings[i].End = timing.End + time.Second
+	}
+	pages, _ := fitVerse(timing, cfg, 1080, 1200, false)
+	lines := 0
+	for _, page := range pages {
+		lines += len(page.Lines)
+	}
+	if len(pages) != 1 || lines != 14 || pages[0].End != timing.End {
+		t.Fatalf("expected the untimed page merged into the first, got %d pages with %d lines", len(pages), lines)
+	}
+}
@@ -0,0 +1,212 @@
+package render
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/image/font/sfnt"
+)
+
+// GlyphReport is the outcome of PrepareGlyphs.
+type GlyphReport struct {
+	// Font is the Arabic font file the render will use; it differs from
+	// video.font.file when a fallback was chosen.
+	Font string
+	// Missing lists Arabic text characters Font cannot draw.
+	Missing []rune
+	// TranslationFont and MissingTranslation are the same for the translation.
+	TranslationFont    string
+	MissingTranslation []rune
+	// Unresolved lists video.glyphs.fallbacks no font file was found for.
+	Unresolved []string
+}
+
+// PrepareGlyphs applies video.glyphs.substitute to the ayah and translation
+// text, then checks every character against the font's cmap. When the
+// configured font lacks some, the first of video.glyphs.fallbacks that covers
+// the whole text replaces it, or else the font missing the fewest. Fonts that
+// cannot be found or read are not checked. Without video.font.file ffmpeg
+// picks the font, so there is nothing to compare fallbacks against and none
+// is used.
+func PrepareGlyphs(input RenderInput) (RenderInput, GlyphReport, error) {
+	subst, err := parseSubstitutions(input.VideoConfig.Glyphs.Substitute)
+	if err != nil {
+		return input, GlyphReport{}, err
+	}
+	if subst != nil {
+		timings := make([]Timing, len(input.Timings))
+		for i, t := range input.Timings {
+			t.Verse.Text = subst.Replace(t.Verse.Text)
+			t.Verse.Translation = subst.Replace(t.Verse.Translation)
+			words := make([]WordTiming, len(t.WordTimings))
+			for j, w := range t.WordTimings {
+				w.Word = subst.Replace(w.Word)
+				words[j] = w
+			}
+			t.WordTimings = words
+			timings[i] = t
+		}
+		input.Timings = timings
+	}
+
+	var arabic, translation strings.Builder
+	for _, t := range input.Timings {
+		arabic.WriteString(t.Verse.Text)
+		for _, w := range t.WordTimings {
+			arabic.WriteString(w.Word)
+		}
+		if input.IncludeTranslation {
+			translation.WriteString(t.Verse.Translation)
+		}
+	}
+	if input.VideoConfig.Elongate {
+		arabic.WriteRune('ـ')
+	}
+
+	var report GlyphReport
+	if font := input.VideoConfig.Font.File; font != "" {
+		if missing, ok := missingGlyphs(font, arabic.String()); ok {
+			report.Font, report.Missing = font, missing
+		}
+		for _, name := range input.VideoConfig.Glyphs.Fallbacks {
+			if report.Font != "" && len(report.Missing) == 0 {
+				break
+			}
+			path := fallbackFontFile(name)
+			if path == "" {
+				report.Unresolved = append(report.Unresolved, name)
+				continue
+			}
+			missing, ok := missingGlyphs(path, arabic.String())
+			if ok && (report.Font == "" || len(missing) < len(report.Missing)) {
+				report.Font, report.Missing = path, missing
+			}
+		}
+		if report.Font != "" {
+			input.VideoConfig.Font.File = report.Font
+		}
+	}
+
+	if input.IncludeTranslation {
+		report.TranslationFont = renderTranslationFont(input)
+		report.MissingTranslation, _ = missingGlyphs(report.TranslationFont, translation.String())
+	}
+	return input, report, nil
+}
+
+// fallbackFontFile takes a font file path as it is and resolves anything else
+// as a family name. It is "" when no installed font matches the family;
+// ResolveFontFile's stock candidates do not count.
+func fallbackFontFile(name string) string {
+	if info, err := os.Stat(name); err == nil && !info.IsDir() {
+		return name
+	}
+	path := ResolveFontFile(name)
+	needle := strings.ToLower(strings.ReplaceAll(name, " ", ""))
+	if needle == "" || !strings.Contains(strings.ToLower(strings.ReplaceAll(filepath.Base(path), " ", "")), needle) {
+		return ""
+	}
+	return path
+}
+
+// renderTranslationFont is the font file the renderer draws the translation
+// with: drawtext uses the ayah font, the others video.translation_font.
+func renderTranslationFont(input RenderInput) string {
+	switch strings.ToLower(input.VideoConfig.Renderer) {
+	case "ass", "subtitles", "image":
+		return translationFontFile(input.VideoConfig)
+	default:
+		return input.VideoConfig.Font.File
+	}
+}
+
+// missingGlyphs returns the characters of text the font has no glyph for,
+// sorted; ok is false when the font cannot be read.
+func missingGlyphs(path, text string) ([]rune, bool) {
+	if path == "" {
+		return nil, false
+	}
+	f, err := loadFont(path)
+	if err != nil {
+		return nil, false
+	}
+	var buf sfnt.Buffer
+	seen := map[rune]bool{}
+	var missing []rune
+	for _, r := range sanitizeText(text) {
+		if seen[r] || unicode.IsSpace(r) || unicode.Is(unicode.Cf, r) {
+			continue
+		}
+		seen[r] = true
+		if idx, err := f.GlyphIndex(&buf, r); err != nil || idx == 0 {
+			missing = append(missing, r)
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool { return missing[i] < missing[j] })
+	return missing, true
+}
+
+// FormatRunes lists characters as "U+06E1 ۡ, U+08F0 ࣰ".
+func FormatRunes(runes []rune) string {
+	parts := make([]string, len(runes))
+	for i, r := range runes {
+		parts[i] = fmt.Sprintf("U+%04X %c", r, r)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// parseSubstitutions builds a replacer from video.glyphs.substitute; nil when
+// there is nothing to replace.
+func parseSubstitutions(pairs map[string]string) (*strings.Replacer, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	keys := make([]string, 0, len(pairs))
+	for k := range pairs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var oldnew []string
+	for _, k := range keys {
+		from, err := parseGlyphText(k)
+		if err != nil {
+			return nil, err
+		}
+		to, err := parseGlyphText(pairs[k])
+		if err != nil {
+			return nil, err
+		}
+		if from == "" {
+			return nil, fmt.Errorf("video.glyphs.substitute: empty key")
+		}
+		oldnew = append(oldnew, from, to)
+	}
+	return strings.NewReplacer(oldnew...), nil
+}
+
+// parseGlyphText reads literal text or one or more space-separated U+XXXX
+// code points.
+func parseGlyphText(value string) (string, error) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 || !strings.HasPrefix(strings.ToUpper(fields[0]), "U+") {
+		return value, nil
+	}
+	var out strings.Builder
+	for _, field := range fields {
+		hex, ok := strings.CutPrefix(strings.ToUpper(field), "U+")
+		if !ok {
+			return "", fmt.Errorf("video.glyphs.substitute: mixed code point value %q", value)
+		}
+		code, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || code > unicode.MaxRune {
+			return "", fmt.Errorf("video.glyphs.substitute: bad code point %q", field)
+		}
+		out.WriteRune(rune(code))
+	}
+	return out.String(), nil
+}
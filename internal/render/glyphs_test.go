@@ -0,0 +1,104 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+
+	"qgencodex/internal/config"
+	"qgencodex/internal/quran"
+)
+
+func glyphInput(t *testing.T, text string) RenderInput {
+	cfg := config.Default().Video
+	cfg.Font.File = filepath.Join(t.TempDir(), "goregular.ttf")
+	if err := os.WriteFile(cfg.Font.File, goregular.TTF, 0o644); err != nil {
+		t.Fatalf("write font: %v", err)
+	}
+	timing := Timing{Verse: quran.Verse{Text: text}, WordTimings: []WordTiming{{Word: text}}}
+	return RenderInput{Timings: []Timing{timing}, VideoConfig: cfg}
+}
+
+func TestPrepareGlyphsReportsMissing(t *testing.T) {
+	// Go Regular has Latin letters but no tanween or small high meem.
+	input := glyphInput(t, "abc ࣰ ۢ a")
+	_, report, err := PrepareGlyphs(input)
+	if err != nil {
+		t.Fatalf("PrepareGlyphs failed: %v", err)
+	}
+	if got := FormatRunes(report.Missing); got != "U+06E2 ۢ, U+08F0 ࣰ" {
+		t.Fatalf("expected the two marks missing, got %q", got)
+	}
+}
+
+func TestPrepareGlyphsSubstitutes(t *testing.T) {
+	input := glyphInput(t, "abc ࣰ")
+	input.VideoConfig.Glyphs.Substitute = map[string]string{"U+08F0": "n"}
+	out, report, err := PrepareGlyphs(input)
+	if err != nil {
+		t.Fatalf("PrepareGlyphs failed: %v", err)
+	}
+	if len(report.Missing) != 0 || out.Timings[0].Verse.Text != "abc n" || out.Timings[0].WordTimings[0].Word != "abc n" {
+		t.Fatalf("expected the tanween substituted, got %+v / %q", report, out.Timings[0].Verse.Text)
+	}
+	if input.Timings[0].Verse.Text != "abc ࣰ" {
+		t.Fatalf("expected the caller's timings untouched")
+	}
+}
+
+func TestPrepareGlyphsFallsBack(t *testing.T) {
+	input := glyphInput(t, "abc")
+	fallback := input.VideoConfig.Font.File
+	input.VideoConfig.Font.File = filepath.Join(t.TempDir(), "missing.ttf")
+	input.VideoConfig.Glyphs.Fallbacks = []string{fallback}
+	out, report, err := PrepareGlyphs(input)
+	if err != nil {
+		t.Fatalf("PrepareGlyphs failed: %v", err)
+	}
+	if report.Font != fallback || out.VideoConfig.Font.File != fallback || len(report.Missing) != 0 {
+		t.Fatalf("expected the fallback font, got %+v", report)
+	}
+}
+
+func TestParseGlyphText(t *testing.T) {
+	if got, err := parseGlyphText("U+0645 U+0652"); err != nil || got != "مْ" {
+		t.Fatalf("expected code points parsed, got %q, %v", got, err)
+	}
+	if got, _ := parseGlyphText("ۢ"); got != "ۢ" {
+		t.Fatalf("expected literal text kept, got %q", got)
+	}
+	if _, err := parseGlyphText("U+ZZZZ"); err == nil {
+		t.Fatalf("expected error for a bad code point")
+	}
+}
+
+func TestPrepareGlyphsWithoutFontFile(t *testing.T) {
+	input := glyphInput(t, "abc ࣰ")
+	fallback := input.VideoConfig.Font.File
+	input.VideoConfig.Font.File = ""
+	input.VideoConfig.Glyphs.Fallbacks = []string{fallback}
+	out, report, err := PrepareGlyphs(input)
+	if err != nil {
+		t.Fatalf("PrepareGlyphs failed: %v", err)
+	}
+	if out.VideoConfig.Font.File != "" || report.Font != "" {
+		t.Fatalf("expected no fallback without a font to compare, got %+v", report)
+	}
+}
+
+func TestPrepareGlyphsUnresolvedFallback(t *testing.T) {
+	input := glyphInput(t, "abc ࣰ")
+	input.VideoConfig.Glyphs.Fallbacks = []string{"No Such Font Family"}
+	out, report, err := PrepareGlyphs(input)
+	if err != nil {
+		t.Fatalf("PrepareGlyphs failed: %v", err)
+	}
+	if len(report.Unresolved) != 1 || report.Unresolved[0] != "No Such Font Family" {
+		t.Fatalf("expected the fallback reported unresolved, got %+v", report)
+	}
+	if out.VideoConfig.Font.File != input.VideoConfig.Font.File {
+		t.Fatalf("expected the configured font kept, got %s", out.VideoConfig.Font.File)
+	}
+}
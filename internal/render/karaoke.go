@@ -0,0 +1,96 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"qgencodex/internal/config"
+)
+
+// karaokeStep is a stretch of an ayah's display with one word highlighted;
+// Word is -1 between words.
+type karaokeStep struct {
+	Start time.Duration
+	End   time.Duration
+	Word  int
+}
+
+// karaokeSteps splits the ayah display into consecutive steps following its
+// word timings, ending at end.
+func karaokeSteps(t Timing, end time.Duration) []karaokeStep {
+	var steps []karaokeStep
+	cursor := t.Start
+	for i, w := range t.WordTimings {
+		start := clampDuration(w.Start, cursor, end)
+		if start > cursor {
+			steps = append(steps, karaokeStep{Start: cursor, End: start, Word: -1})
+		}
+		wordEnd := clampDuration(w.End, start, end)
+		if wordEnd > start {
+			steps = append(steps, karaokeStep{Start: start, End: wordEnd, Word: i})
+		}
+		cursor = wordEnd
+	}
+	if cursor < end {
+		steps = append(steps, karaokeStep{Start: cursor, End: end, Word: -1})
+	}
+	return steps
+}
+
+// karaokeEnd keeps an ayah from overlapping the next one on screen.
+func karaokeEnd(timings []Timing, i int) time.Duration {
+	end := timings[i].End
+	if i+1 < len(timings) && timings[i+1].Start < end && timings[i+1].Start > timings[i].Start {
+		end = timings[i+1].Start
+	}
+	return end
+}
+
+func clampDuration(d, lo, hi time.Duration) time.Duration {
+	if d < lo {
+		return lo
+	}
+	if d > hi {
+		return hi
+	}
+	return d
+}
+
+func karaokeStyle(cfg config.VideoConfig) string {
+	style := strings.ToLower(strings.TrimSpace(cfg.Karaoke.Style))
+	if style == "" {
+		return "color"
+	}
+	return style
+}
+
+// assKaraokeWord wraps the highlighted word in override tags and resets to the
+// style afterwards.
+func assKaraokeWord(cfg config.VideoConfig, word string) string {
+	color := assColor(cfg.Karaoke.Color, "#FFD700")
+	switch karaokeStyle(cfg) {
+	case "glow":
+		glow := maxInt(cfg.Font.OutlineWidth, 1) + 3
+		return fmt.Sprintf("{\\3c%s&\\bord%d\\blur4}%s{\\r}", color, glow, word)
+	case "underline":
+		return fmt.Sprintf("{\\u1}%s{\\u0}", word)
+	default:
+		return fmt.Sprintf("{\\c%s&}%s{\\r}", color, word)
+	}
+}
+
+// assStepFade fades the ayah in on its first step and out on its last.
+func assStepFade(cfg config.VideoConfig, first, last bool) string {
+	fi, fo := cfg.FadeInMs, cfg.FadeOutMs
+	if !first || fi < 0 {
+		fi = 0
+	}
+	if !last || fo < 0 {
+		fo = 0
+	}
+	if fi == 0 && fo == 0 {
+		return ""
+	}
+	return fmt.Sprintf("{\\fad(%d,%d)}", fi, fo)
+}
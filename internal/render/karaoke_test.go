@@ -0,0 +1,117 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/image/font/gofont/goregular"
+
+	"qgencodex/internal/config"
+	"qgencodex/internal/quran"
+)
+
+func karaokeTimings() []Timing {
+	return []Timing{
+		{
+			Verse: quran.Verse{Text: "بسم الله"},
+			End:   2 * time.Second,
+			WordTimings: []WordTiming{
+				{Word: "بسم", Start: 200 * time.Millisecond, End: 800 * time.Millisecond},
+				{Word: "الله", Start: 1 * time.Second, End: 1800 * time.Millisecond},
+			},
+		},
+		{Verse: quran.Verse{Text: "الرحمن"}, Start: 1900 * time.Millisecond, End: 3 * time.Second},
+	}
+}
+
+func TestKaraokeSteps(t *testing.T) {
+	timings := karaokeTimings()
+	steps := karaokeSteps(timings[0], karaokeEnd(timings, 0))
+	want := []karaokeStep{
+		{Start: 0, End: 200 * time.Millisecond, Word: -1},
+		{Start: 200 * time.Millisecond, End: 800 * time.Millisecond, Word: 0},
+		{Start: 800 * time.Millisecond, End: 1 * time.Second, Word: -1},
+		{Start: 1 * time.Second, End: 1800 * time.Millisecond, Word: 1},
+		{Start: 1800 * time.Millisecond, End: 1900 * time.Millisecond, Word: -1},
+	}
+	if len(steps) != len(want) {
+		t.Fatalf("expected %d steps, got %+v", len(want), steps)
+	}
+	for i := range want {
+		if steps[i] != want[i] {
+			t.Fatalf("step %d: expected %+v, got %+v", i, want[i], steps[i])
+		}
+	}
+}
+
+func TestASSKaraokeHighlightsCurrentWord(t *testing.T) {
+	cfg := config.Default().Video
+	cfg.FadeInMs, cfg.FadeOutMs = 100, 100
+	opts := assOptions{Width: 1080, Height: 1920, Mode: "karaoke", Timings: karaokeTimings(), Config: cfg}
+	content := assContent(t, opts)
+	if strings.Count(content, "Dialogue:") != 6 {
+		t.Fatalf("expected one event per step:\n%s", content)
+	}
+	if !strings.Contains(content, "0:00:00.20,0:00:00.80,Default,,0,0,0,,{\\fnAmiri Quran}{\\c&H0000D7FF&}بسم{\\r}{\\fnAmiri Quran} الله") {
+		t.Fatalf("expected the first word highlighted while it is recited:\n%s", content)
+	}
+	if strings.Count(content, "\\fad(") != 3 {
+		t.Fatalf("expected fades only at the start and end of each ayah:\n%s", content)
+	}
+
+	opts.Config.Karaoke.Style = "underline"
+	if content := assContent(t, opts); !strings.Contains(content, "{\\u1}الله{\\u0}") {
+		t.Fatalf("expected an underlined word:\n%s", content)
+	}
+}
+
+func TestWordSpan(t *testing.T) {
+	clusters := shapeArabic("بسم الله")
+	// Visual order puts the second word first.
+	if got := wordSpan(clusters, 1); got != [2]int{0, 4} {
+		t.Fatalf("expected span of the second word, got %v", got)
+	}
+	if got := wordSpan(clusters, 0); got != [2]int{5, 8} {
+		t.Fatalf("expected span of the first word, got %v", got)
+	}
+}
+
+func TestBuildImageFiltersKaraoke(t *testing.T) {
+	dir := t.TempDir()
+	fontPath := filepath.Join(dir, "Go-Regular.ttf")
+	if err := os.WriteFile(fontPath, goregular.TTF, 0o644); err != nil {
+		t.Fatalf("write font: %v", err)
+	}
+	cfg := config.Default().Video
+	cfg.Font.File = fontPath
+	cfg.FadeInMs, cfg.FadeOutMs = 200, 200
+	cfg.Karaoke.Style = "glow"
+	input := RenderInput{Timings: karaokeTimings(), TempDir: dir, Mode: "karaoke", VideoConfig: cfg}
+	filters, err := buildFilters(input, 1080, 1920)
+	if err != nil {
+		t.Fatalf("buildFilters failed: %v", err)
+	}
+	if strings.Count(filters, "movie=") != 6 || strings.Count(filters, "fade=t=in") != 2 {
+		t.Fatalf("unexpected filters: %s", filters)
+	}
+	// Faded cards loop only over their own window.
+	if strings.Contains(filters, "trim=end") || !strings.Contains(filters, "trim=duration=") {
+		t.Fatalf("expected looped cards trimmed to their window: %s", filters)
+	}
+}
+
+func TestDrawtextKaraokeWithoutFontFile(t *testing.T) {
+	cfg := config.Default().Video
+	cfg.Font.File = ""
+	input := RenderInput{Timings: karaokeTimings(), TempDir: t.TempDir(), Mode: "karaoke", VideoConfig: cfg}
+	filters, err := buildFilters(input, 1080, 1920)
+	if err != nil {
+		t.Fatalf("buildFilters failed: %v", err)
+	}
+	if !strings.Contains(filters, "subtitles=") {
+		t.Fatalf("expected the ASS renderer without a font file: %s", filters)
+	}
+}
@@ -0,0 +1,83 @@
+package render
+
+import (
+	"strings"
+	"time"
+
+	"qgencodex/internal/config"
+)
+
+// lineScreen is a group of an ayah's wrapped lines shown together in
+// line-by-line mode, with its share of the translation.
+type lineScreen struct {
+	Lines       []string
+	Translation string
+	Start       time.Duration
+	End         time.Duration
+}
+
+// buildLineScreens wraps an ayah and shows cfg.Lines lines at a time. Each
+// screen starts when its first word is recited (the first at the ayah start)
+// and lasts until the next one, the last until end. With split translation
+// each screen gets the translation words in proportion to its ayah words.
+func buildLineScreens(t Timing, end time.Duration, maxWidth, fontSize int, fontFile string, cfg config.LineConfig) []lineScreen {
+	lines := wrapText(t.Verse.Text, maxWidth, fontSize, fontFile)
+	perScreen := cfg.Lines
+	if perScreen <= 0 {
+		perScreen = 2
+	}
+	starts := wordStarts(t, end)
+	offsets := lineWordOffsets(t.Verse.Text, lines)
+	total := len(starts)
+	translation := strings.Fields(t.Verse.Translation)
+	split := !strings.EqualFold(cfg.Translation, "whole")
+
+	var screens []lineScreen
+	for i := 0; i < len(lines); i += perScreen {
+		next := minInt(i+perScreen, len(lines))
+		screen := lineScreen{Lines: lines[i:next], Start: t.Start, End: end}
+		from, word := offsets[i], offsets[next]
+		if len(screens) > 0 && from < len(starts) {
+			screen.Start = starts[from]
+			screens[len(screens)-1].End = screen.Start
+		}
+		if !split {
+			screen.Translation = t.Verse.Translation
+		} else if total > 0 {
+			screen.Translation = strings.Join(translation[len(translation)*from/total:len(translation)*word/total], " ")
+		}
+		screens = append(screens, screen)
+	}
+	return screens
+}
+
+// lineWordOffsets returns, for each wrapped line of text, the index of its
+// first word among strings.Fields(sanitizeText(text)), the words wordStarts
+// times, followed by the word count. A line holding the rest of a word split
+// by wrapText starts at that word.
+func lineWordOffsets(text string, lines []string) []int {
+	words := strings.Fields(sanitizeText(text))
+	offsets := make([]int, 0, len(lines)+1)
+	word, partial := 0, ""
+	for _, line := range lines {
+		offsets = append(offsets, minInt(word, len(words)))
+		for _, token := range strings.Fields(line) {
+			if word < len(words) && partial+token != words[word] && strings.HasPrefix(words[word], partial+token) {
+				partial += token
+				continue
+			}
+			word, partial = word+1, ""
+		}
+	}
+	return append(offsets, len(words))
+}
+
+// wordStarts returns when each word of the ayah text is recited, spreading
+// the words over the ayah when its word timings are unusable.
+func wordStarts(t Timing, end time.Duration) []time.Duration {
+	words := strings.Fields(sanitizeText(t.Verse.Text))
+	if !validWordTimings(t.WordTimings) {
+		t.WordTimings = SplitWordTimings(words, t.Start, end)
+	}
+	return revealTimes(t, len(words), end)
+}
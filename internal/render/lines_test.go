@@ -0,0 +1,75 @@
+package render
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"qgencodex/internal/config"
+	"qgencodex/internal/quran"
+)
+
+func lineTiming() Timing {
+	words := strings.Fields("aaaa bbbb cccc dddd eeee ffff gggg hhhh")
+	timing := Timing{
+		Verse: quran.Verse{Text: strings.Join(words, " "), Translation: "a b c d e f g h i j k l"},
+		End:   8 * time.Second,
+	}
+	timing.WordTimings = timedWords(words...)
+	return timing
+}
+
+func TestBuildLineScreens(t *testing.T) {
+	timing := lineTiming()
+	// Narrow enough for two words per line: four lines, two screens.
+	maxWidth := int(approxWidth("aaaa bbbb", 40)/wrapThreshold) + 1
+	screens := buildLineScreens(timing, 8*time.Second, maxWidth, 40, "", config.LineConfig{Lines: 2, Translation: "split"})
+	if len(screens) != 2 {
+		t.Fatalf("expected two screens, got %+v", screens)
+	}
+	if screens[0].Start != 0 || screens[0].End != 4*time.Second || screens[1].End != 8*time.Second {
+		t.Fatalf("expected screens to switch when the fifth word starts, got %+v", screens)
+	}
+	if screens[0].Translation != "a b c d e f" || screens[1].Translation != "g h i j k l" {
+		t.Fatalf("expected the translation split in half, got %q / %q", screens[0].Translation, screens[1].Translation)
+	}
+	screens = buildLineScreens(timing, 8*time.Second, maxWidth, 40, "", config.LineConfig{Lines: 1, Translation: "whole"})
+	if len(screens) != 4 || screens[3].Translation != timing.Verse.Translation || screens[1].Start != 2*time.Second {
+		t.Fatalf("expected one line per screen with the whole translation, got %+v", screens)
+	}
+}
+
+func TestDrawtextLineByLine(t *testing.T) {
+	cfg := config.Default().Video
+	cfg.Reference.Enabled = false
+	cfg.LineByLine.Lines = 10
+	input := RenderInput{
+		Timings:            []Timing{lineTiming()},
+		TempDir:            t.TempDir(),
+		Mode:               "line-by-line",
+		VideoConfig:        cfg,
+		IncludeTranslation: true,
+	}
+	filters, err := buildDrawtextFilters(input, 1080, 1920)
+	if err != nil {
+		t.Fatalf("buildDrawtextFilters failed: %v", err)
+	}
+	if !strings.Contains(filters, "enable='between(t,0.000,8.000)'") || strings.Count(filters, "drawtext=") != 2 {
+		t.Fatalf("expected every line on one screen with its translation: %s", filters)
+	}
+}
+
+func TestLineWordOffsetsSplitWord(t *testing.T) {
+	// wrapText split the long middle word over three lines.
+	lines := []string{"aaaa bbbb", "bbbb", "bbbb cccc"}
+	got := lineWordOffsets("\ufeffaaaa bbbbbbbbbbbb cccc", lines)
+	want := []int{0, 1, 1, 3}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package render
+
+import (
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font"
+
+	"qgencodex/internal/config"
+)
+
+type faceKey struct {
+	path string
+	size int
+}
+
+var (
+	// measureMu guards measureFaces; faces are not safe for concurrent use.
+	measureMu    sync.Mutex
+	measureFaces = map[faceKey]font.Face{}
+
+	translationFontsMu sync.Mutex
+	translationFonts   = map[string]string{}
+)
+
+// textWidth measures text at fontSize from the font's glyph advances after
+// Arabic shaping. Without a font file, or when it cannot be read, it falls
+// back to approxWidth, as it does for runes the font has no glyph for.
+func textWidth(fontFile, text string, fontSize int) float64 {
+	if fontFile == "" || fontSize <= 0 {
+		return approxWidth(text, fontSize)
+	}
+	measureMu.Lock()
+	defer measureMu.Unlock()
+	key := faceKey{path: fontFile, size: fontSize}
+	face, ok := measureFaces[key]
+	if !ok {
+		// A failed load is cached as nil so it is not retried per word.
+		face, _ = newFace(fontFile, fontSize)
+		measureFaces[key] = face
+	}
+	if face == nil {
+		return approxWidth(text, fontSize)
+	}
+	width := 0.0
+	for _, c := range shapeArabic(sanitizeText(text)) {
+		advance, ok := face.GlyphAdvance(c.Base)
+		if !ok && c.Plain != c.Base {
+			advance, ok = face.GlyphAdvance(c.Plain)
+		}
+		if !ok {
+			width += approxWidth(string(c.Plain), fontSize)
+			continue
+		}
+		width += float64(advance) / 64
+	}
+	return width
+}
+
+// translationFontFile resolves video.translation_font once per family; empty
+// means the translation is measured with the heuristic.
+func translationFontFile(cfg config.VideoConfig) string {
+	family := strings.TrimSpace(cfg.TranslationFont)
+	if family == "" {
+		return ""
+	}
+	translationFontsMu.Lock()
+	defer translationFontsMu.Unlock()
+	if path, ok := translationFonts[family]; ok {
+		return path
+	}
+	path := ResolveFontFile(family)
+	translationFonts[family] = path
+	return path
+}
@@ -0,0 +1,35 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestTextWidthUsesFontAdvances(t *testing.T) {
+	fontPath := filepath.Join(t.TempDir(), "goregular.ttf")
+	if err := os.WriteFile(fontPath, goregular.TTF, 0o644); err != nil {
+		t.Fatalf("write font: %v", err)
+	}
+	narrow, wide := textWidth(fontPath, "iiii", 40), textWidth(fontPath, "MMMM", 40)
+	if narrow >= wide || approxWidth("iiii", 40) != approxWidth("MMMM", 40) {
+		t.Fatalf("expected measured widths to differ by glyph, got %.1f and %.1f", narrow, wide)
+	}
+	// The heuristic wraps six narrow words at 240px; their real advances fit one line.
+	text := "iii iii iii iii iii iii"
+	if got := wrapText(text, 240, 40, fontPath); len(got) != 1 {
+		t.Fatalf("expected one measured line, got %q", got)
+	}
+	if got := wrapText(text, 240, 40, ""); len(got) < 2 {
+		t.Fatalf("expected the heuristic to wrap, got %q", got)
+	}
+}
+
+func TestTextWidthFallsBackWithoutFont(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.ttf")
+	if got, want := textWidth(missing, "قل هو الله أحد", 64), approxWidth("قل هو الله أحد", 64); got != want {
+		t.Fatalf("expected the heuristic for an unreadable font, got %.1f want %.1f", got, want)
+	}
+}
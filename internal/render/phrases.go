@@ -0,0 +1,84 @@
+package render
+
+import (
+	"strings"
+	"time"
+
+	"qgencodex/internal/config"
+)
+
+// isStopMark reports whether r is a waqf sign where a reciter may stop: the
+// compulsory, permissible and preferred stops and the paired (mu'anaqah)
+// signs. The "do not stop" sign is excluded.
+func isStopMark(r rune) bool {
+	switch r {
+	case 'ۖ', 'ۗ', 'ۘ', 'ۚ', 'ۛ':
+		return true
+	default:
+		return false
+	}
+}
+
+// isMarkOnly reports whether word is a standalone pause sign.
+func isMarkOnly(word string) bool {
+	return strings.TrimFunc(word, func(r rune) bool { return isWaqfMark(r) || isCombining(r) }) == ""
+}
+
+// buildPhrases chunks an ayah at waqf signs, and at pauses of at least
+// cfg.PauseMs between words, so each phrase is read in one breath. Phrases
+// longer than cfg.MaxWords are split evenly.
+func buildPhrases(t Timing, cfg config.PhraseConfig) []wordChunk {
+	if len(t.WordTimings) > 0 {
+		if phrases := phrasesFromWords(t.WordTimings, cfg); validPhrases(phrases) {
+			return phrases
+		}
+	}
+	return phrasesFromWords(SplitWordTimings(strings.Fields(t.Verse.Text), t.Start, t.End), cfg)
+}
+
+func phrasesFromWords(words []WordTiming, cfg config.PhraseConfig) []wordChunk {
+	pause := time.Duration(cfg.PauseMs) * time.Millisecond
+	var phrases []wordChunk
+	from := 0
+	for i, w := range words {
+		end := i == len(words)-1 || strings.IndexFunc(w.Word, isStopMark) >= 0
+		if !end && pause > 0 && !isMarkOnly(words[i+1].Word) && words[i+1].Start-w.End >= pause {
+			end = true
+		}
+		if end {
+			phrases = append(phrases, splitPhrase(words[from:i+1], cfg.MaxWords)...)
+			from = i + 1
+		}
+	}
+	// Hold each phrase until the next one starts, so the breath between them
+	// does not blank the screen.
+	for i := 0; i+1 < len(phrases); i++ {
+		if phrases[i+1].Start > phrases[i].End {
+			phrases[i].End = phrases[i+1].Start
+		}
+	}
+	return phrases
+}
+
+// splitPhrase joins words into one phrase, or into the fewest even chunks of
+// at most maxWords.
+func splitPhrase(words []WordTiming, maxWords int) []wordChunk {
+	chunks := 1
+	if maxWords > 0 && len(words) > maxWords {
+		chunks = (len(words) + maxWords - 1) / maxWords
+	}
+	out := make([]wordChunk, 0, chunks)
+	for c := 0; c < chunks; c++ {
+		out = append(out, joinChunk(words[len(words)*c/chunks:len(words)*(c+1)/chunks]))
+	}
+	return out
+}
+
+func validPhrases(phrases []wordChunk) bool {
+	for _, p := range phrases {
+		if p.End <= p.Start || p.Text == "" {
+			return false
+		}
+	}
+	return true
+}
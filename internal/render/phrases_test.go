@@ -0,0 +1,69 @@
+package render
+
+import (
+	"testing"
+	"time"
+
+	"qgencodex/internal/config"
+	"qgencodex/internal/quran"
+)
+
+func timedWords(words ...string) []WordTiming {
+	out := make([]WordTiming, len(words))
+	for i, w := range words {
+		out[i] = WordTiming{Word: w, Start: time.Duration(i) * time.Second, End: time.Duration(i+1) * time.Second}
+	}
+	return out
+}
+
+func phraseTexts(phrases []wordChunk) []string {
+	out := make([]string, len(phrases))
+	for i, p := range phrases {
+		out[i] = p.Text
+	}
+	return out
+}
+
+func TestBuildPhrasesSplitsAtWaqfSigns(t *testing.T) {
+	// Standalone and attached stop signs end a phrase; the "do not stop" sign does not.
+	timing := Timing{WordTimings: timedWords("ذَٰلِكَ", "ٱلْكِتَٰبُ", "لَا", "رَيْبَ", "ۛ", "فِيهِۛ", "هُدًى", "لِّلْمُتَّقِينَۙ", "ٱلَّذِينَ")}
+	got := phraseTexts(buildPhrases(timing, config.PhraseConfig{}))
+	want := []string{"ذَٰلِكَ ٱلْكِتَٰبُ لَا رَيْبَ ۛ", "فِيهِۛ", "هُدًى لِّلْمُتَّقِينَۙ ٱلَّذِينَ"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %q, got %q", want, got)
+		}
+	}
+}
+
+func TestBuildPhrasesPausesAndMaxWords(t *testing.T) {
+	words := timedWords("a", "b", "c", "d", "e", "f", "g")
+	// A 700ms breath after "c".
+	for i := 3; i < len(words); i++ {
+		words[i].Start += 700 * time.Millisecond
+		words[i].End += 700 * time.Millisecond
+	}
+	phrases := buildPhrases(Timing{WordTimings: words}, config.PhraseConfig{MaxWords: 3, PauseMs: 500})
+	got := phraseTexts(phrases)
+	want := []string{"a b c", "d e", "f g"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if phrases[0].End != phrases[1].Start {
+		t.Fatalf("expected the first phrase held through the pause, got %+v", phrases)
+	}
+	if got := buildPhrases(Timing{WordTimings: words}, config.PhraseConfig{}); len(got) != 1 {
+		t.Fatalf("expected one phrase with pauses disabled, got %q", phraseTexts(got))
+	}
+}
+
+func TestBuildPhrasesWithoutWordTimings(t *testing.T) {
+	timing := Timing{Verse: quran.Verse{Text: "قُلْ هُوَ ٱللَّهُ ۚ أَحَدٌ"}, Start: time.Second, End: 5 * time.Second}
+	phrases := buildPhrases(timing, config.PhraseConfig{})
+	if len(phrases) != 2 || phrases[0].Start != time.Second || phrases[1].End != 5*time.Second {
+		t.Fatalf("expected two phrases spanning the ayah, got %+v", phrases)
+	}
+}
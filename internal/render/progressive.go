@@ -0,0 +1,74 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// revealTimes returns when each of the ayah's n words appears in progressive
+// mode. Words without a timing appear with the last timed one.
+func revealTimes(t Timing, n int, end time.Duration) []time.Duration {
+	times := make([]time.Duration, n)
+	cursor := t.Start
+	for i := range times {
+		if i < len(t.WordTimings) {
+			cursor = clampDuration(t.WordTimings[i].Start, cursor, end)
+		}
+		times[i] = cursor
+	}
+	return times
+}
+
+// buildProgressiveDrawtext draws each wrapped line as a growing prefix of its
+// words. A prefix is right-aligned to where the whole line ends, and lines sit
+// where the full ayah would, so revealed words never move.
+func buildProgressiveDrawtext(input RenderInput, idx int, lines []string, fontSize int, textY string, end time.Duration) ([]string, error) {
+	t := input.Timings[idx]
+	cfg := input.VideoConfig
+	spacing := cfg.LineSpacing
+	if spacing == 0 {
+		spacing = 10
+	}
+	total := 0
+	for _, line := range lines {
+		total += len(strings.Fields(line))
+	}
+	reveal := revealTimes(t, total, end)
+	block := fmt.Sprintf("(%d*line_h+%d)", len(lines), (len(lines)-1)*spacing)
+	top := strings.ReplaceAll(textY, "text_h", block)
+
+	var filters []string
+	word := 0
+	for li, line := range lines {
+		words := strings.Fields(line)
+		x := fmt.Sprintf("(w+%d)/2-text_w", int(textWidth(input.VideoConfig.Font.File, line, fontSize)))
+		y := fmt.Sprintf("%s+%d*(line_h+%d)", top, li, spacing)
+		for k := range words {
+			start, stop := reveal[word], end
+			if k+1 < len(words) {
+				stop = reveal[word+1]
+			}
+			fadeIn, fadeOut := word == 0, k+1 == len(words)
+			word++
+			if stop <= start {
+				continue
+			}
+			textFile, err := writeTextFile(input.TempDir, fmt.Sprintf("ayah_%d_line_%d_reveal_%d.txt", idx, li, k), strings.Join(words[:k+1], " "))
+			if err != nil {
+				return nil, err
+			}
+			enable := fmt.Sprintf("between(t,%.3f,%.3f)", start.Seconds(), stop.Seconds())
+			fadeCfg := cfg
+			if !fadeIn {
+				fadeCfg.FadeInMs = 0
+			}
+			if !fadeOut {
+				fadeCfg.FadeOutMs = 0
+			}
+			fade := fadeAlphaExpr(fadeCfg, start, stop)
+			filters = append(filters, drawtextArgsAt(textFile, enable, cfg, fontSize, cfg.Font.Color, x, y, fade))
+		}
+	}
+	return filters, nil
+}
@@ -0,0 +1,71 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"qgencodex/internal/config"
+	"qgencodex/internal/quran"
+)
+
+func TestRevealTimes(t *testing.T) {
+	timing := Timing{
+		Start: 1 * time.Second,
+		WordTimings: []WordTiming{
+			{Start: 500 * time.Millisecond},
+			{Start: 2 * time.Second},
+		},
+	}
+	got := revealTimes(timing, 3, 3*time.Second)
+	want := []time.Duration{1 * time.Second, 2 * time.Second, 2 * time.Second}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestASSProgressiveHidesUnrecitedWords(t *testing.T) {
+	opts := assOptions{Width: 1080, Height: 1920, Mode: "progressive", Timings: karaokeTimings(), Config: config.Default().Video}
+	content := assContent(t, opts)
+	if !strings.Contains(content, "0:00:00.20,0:00:01.00,Default,,0,0,0,,{\\fad(120,0)}{\\fnAmiri Quran}بسم {\\alpha&HFF&}الله{\\r}") {
+		t.Fatalf("expected the second word hidden until it is recited:\n%s", content)
+	}
+	if !strings.Contains(content, "0:00:01.00,0:00:01.90,Default,,0,0,0,,{\\fad(0,120)}{\\fnAmiri Quran}بسم الله\n") {
+		t.Fatalf("expected the whole ayah once every word is recited:\n%s", content)
+	}
+}
+
+func TestDrawtextProgressiveKeepsLinePositions(t *testing.T) {
+	input := RenderInput{
+		Timings: []Timing{{
+			Verse: quran.Verse{Text: "قل هو الله أحد"},
+			End:   4 * time.Second,
+			WordTimings: []WordTiming{
+				{Start: 0, End: time.Second},
+				{Start: time.Second, End: 2 * time.Second},
+				{Start: 2 * time.Second, End: 3 * time.Second},
+				{Start: 3 * time.Second, End: 4 * time.Second},
+			},
+		}},
+		TempDir:     t.TempDir(),
+		Mode:        "progressive",
+		VideoConfig: config.Default().Video,
+	}
+	filters, err := buildDrawtextFilters(input, 1080, 1920)
+	if err != nil {
+		t.Fatalf("buildDrawtextFilters failed: %v", err)
+	}
+	if strings.Count(filters, "drawtext=") != 4 {
+		t.Fatalf("expected one drawtext per revealed word: %s", filters)
+	}
+	x := fmt.Sprintf("x=(w+%d)/2-text_w", int(approxWidth("قل هو الله أحد", 64)))
+	if strings.Count(filters, x) != 4 {
+		t.Fatalf("expected every prefix aligned to the line end %s: %s", x, filters)
+	}
+	if !strings.Contains(filters, "enable='between(t,3.000,4.000)'") {
+		t.Fatalf("expected the full line until the ayah ends: %s", filters)
+	}
+}
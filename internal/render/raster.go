@@ -0,0 +1,309 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+
+	"qgencodex/internal/config"
+)
+
+var (
+	fontCacheMu sync.Mutex
+	fontCache   = map[string]*opentype.Font{}
+)
+
+// loadFont parses a TrueType/OpenType file once per path. An empty path
+// selects the bundled Go Regular font.
+func loadFont(path string) (*opentype.Font, error) {
+	fontCacheMu.Lock()
+	defer fontCacheMu.Unlock()
+	if f, ok := fontCache[path]; ok {
+		return f, nil
+	}
+	data := goregular.TTF
+	if path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	f, err := opentype.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse font %s: %w", path, err)
+	}
+	fontCache[path] = f
+	return f, nil
+}
+
+func newFace(path string, size int) (font.Face, error) {
+	f, err := loadFont(path)
+	if err != nil {
+		return nil, err
+	}
+	return opentype.NewFace(f, &opentype.FaceOptions{Size: float64(size), DPI: 72, Hinting: font.HintingNone})
+}
+
+// placedGlyph is a glyph at a pen offset from the start of the line, drawn
+// for the cluster at that index.
+type placedGlyph struct {
+	r       rune
+	x       fixed.Int26_6
+	dy      fixed.Int26_6
+	cluster int
+}
+
+// layoutLine places shaped clusters left to right. Marks are centered on their
+// base and pushed clear of it, stacking when a letter carries several.
+func layoutLine(face font.Face, clusters []shapedCluster) ([]placedGlyph, fixed.Int26_6) {
+	var (
+		glyphs []placedGlyph
+		x      fixed.Int26_6
+	)
+	gap := face.Metrics().Height / 20
+	for i, c := range clusters {
+		base := c.Base
+		advance, ok := face.GlyphAdvance(base)
+		if !ok && c.Plain != base {
+			base = c.Plain
+			advance, _ = face.GlyphAdvance(base)
+		}
+		glyphs = append(glyphs, placedGlyph{r: base, x: x, cluster: i})
+		baseBounds, _, _ := face.GlyphBounds(base)
+		top, bottom := baseBounds.Min.Y, baseBounds.Max.Y
+		for _, m := range c.Marks {
+			bounds, _, ok := face.GlyphBounds(m)
+			if !ok {
+				continue
+			}
+			g := placedGlyph{r: m, x: x + advance/2 - (bounds.Min.X+bounds.Max.X)/2, cluster: i}
+			if bounds.Min.Y+bounds.Max.Y < 0 {
+				if bounds.Max.Y > top-gap {
+					g.dy = top - gap - bounds.Max.Y
+				}
+				top = bounds.Min.Y + g.dy
+			} else {
+				if bounds.Min.Y < bottom+gap {
+					g.dy = bottom + gap - bounds.Min.Y
+				}
+				bottom = bounds.Max.Y + g.dy
+			}
+			glyphs = append(glyphs, g)
+		}
+		x += advance
+	}
+	return glyphs, x
+}
+
+// plainClusters keeps left-to-right text (translations) as it is.
+func plainClusters(text string) []shapedCluster {
+	runes := []rune(sanitizeText(text))
+	out := make([]shapedCluster, 0, len(runes))
+	for _, r := range runes {
+		out = append(out, shapedCluster{Base: r, Plain: r})
+	}
+	return out
+}
+
+// cardLine is one line of a text card.
+type cardLine struct {
+	face  font.Face
+	text  []shapedCluster
+	color color.Color
+	// gapBefore is extra space above the line.
+	gapBefore int
+	// highlight is the cluster range of the karaoke word; empty when from >= to.
+	highlight [2]int
+	// hidden clusters keep their space but are not drawn (progressive reveal).
+	hidden [2]int
+}
+
+func (l cardLine) highlighted(g placedGlyph) bool {
+	return g.cluster >= l.highlight[0] && g.cluster < l.highlight[1]
+}
+
+// drawCard rasterizes lines centered on a transparent image, with the outline,
+// shadow and glass box of the video config.
+func drawCard(cfg config.VideoConfig, lines []cardLine) *image.RGBA {
+	type laidOut struct {
+		glyphs []placedGlyph
+		width  int
+		ascent int
+		height int
+	}
+	lineSpacing := cfg.LineSpacing
+	if lineSpacing == 0 {
+		lineSpacing = 10
+	}
+	outline := cfg.Font.OutlineWidth
+	glow := 0
+	if karaokeStyle(cfg) == "glow" {
+		glow = outline + 4
+	}
+	pad := maxInt(outline, glow) + maxInt(absInt(cfg.Font.ShadowX), absInt(cfg.Font.ShadowY))
+	if cfg.Glass.Enabled {
+		padding := cfg.Glass.Padding
+		if padding <= 0 {
+			padding = 12
+		}
+		pad += padding
+	}
+	laid := make([]laidOut, len(lines))
+	width, height := 0, 0
+	for i, line := range lines {
+		glyphs, advance := layoutLine(line.face, line.text)
+		if line.hidden[0] < line.hidden[1] {
+			visible := glyphs[:0:0]
+			for _, g := range glyphs {
+				if g.cluster < line.hidden[0] || g.cluster >= line.hidden[1] {
+					visible = append(visible, g)
+				}
+			}
+			glyphs = visible
+		}
+		metrics := line.face.Metrics()
+		laid[i] = laidOut{glyphs: glyphs, width: advance.Ceil(), ascent: metrics.Ascent.Ceil(), height: (metrics.Ascent + metrics.Descent).Ceil()}
+		width = maxInt(width, laid[i].width)
+		if i > 0 {
+			height += lineSpacing + line.gapBefore
+		}
+		height += laid[i].height
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width+2*pad, height+2*pad))
+	if cfg.Glass.Enabled {
+		alpha := cfg.Glass.Alpha
+		if alpha <= 0 || alpha > 1 {
+			alpha = 0.35
+		}
+		draw.Draw(img, img.Bounds(), image.NewUniform(withAlpha(parseHexColor(cfg.Glass.Color, color.Black), alpha)), image.Point{}, draw.Src)
+	}
+	outlineColor := parseHexColor(cfg.Font.OutlineColor, color.Black)
+	shadowColor := parseHexColor(cfg.Font.ShadowColor, color.Black)
+	karaokeColor := parseHexColor(cfg.Karaoke.Color, color.RGBA{R: 0xFF, G: 0xD7, A: 0xFF})
+	y := pad
+	for i, line := range lines {
+		if i > 0 {
+			y += lineSpacing + line.gapBefore
+		}
+		origin := fixed.P(pad+(width-laid[i].width)/2, y+laid[i].ascent)
+		var word []placedGlyph
+		for _, g := range laid[i].glyphs {
+			if line.highlighted(g) {
+				word = append(word, g)
+			}
+		}
+		if len(word) > 0 && glow > 0 {
+			halo := withAlpha(karaokeColor, 0.25)
+			for dx := -glow; dx <= glow; dx += 2 {
+				for dy := -glow; dy <= glow; dy += 2 {
+					if dx*dx+dy*dy <= glow*glow {
+						drawGlyphs(img, line.face, word, origin.Add(fixed.P(dx, dy)), halo)
+					}
+				}
+			}
+		}
+		if cfg.Font.ShadowX != 0 || cfg.Font.ShadowY != 0 {
+			drawGlyphs(img, line.face, laid[i].glyphs, origin.Add(fixed.P(cfg.Font.ShadowX, cfg.Font.ShadowY)), shadowColor)
+		}
+		for dx := -outline; dx <= outline; dx++ {
+			for dy := -outline; dy <= outline; dy++ {
+				if (dx != 0 || dy != 0) && dx*dx+dy*dy <= outline*outline {
+					drawGlyphs(img, line.face, laid[i].glyphs, origin.Add(fixed.P(dx, dy)), outlineColor)
+				}
+			}
+		}
+		drawGlyphs(img, line.face, laid[i].glyphs, origin, line.color)
+		if len(word) > 0 {
+			switch karaokeStyle(cfg) {
+			case "color":
+				drawGlyphs(img, line.face, word, origin, karaokeColor)
+			case "underline":
+				drawUnderline(img, line, laid[i].glyphs, origin, karaokeColor)
+			}
+		}
+		y += laid[i].height
+	}
+	return img
+}
+
+// drawUnderline rules a bar under the highlighted clusters, just below the
+// baseline.
+func drawUnderline(dst draw.Image, line cardLine, glyphs []placedGlyph, origin fixed.Point26_6, c color.Color) {
+	from, to := fixed.Int26_6(-1), fixed.Int26_6(-1)
+	for _, g := range glyphs {
+		if g.cluster < line.highlight[0] || g.r == ' ' {
+			continue
+		}
+		if g.cluster >= line.highlight[1] {
+			to = g.x
+			break
+		}
+		if from < 0 {
+			from = g.x
+		}
+	}
+	if from < 0 {
+		return
+	}
+	if to < 0 {
+		_, advance := layoutLine(line.face, line.text)
+		to = advance
+	}
+	metrics := line.face.Metrics()
+	thickness := maxInt(metrics.Height.Ceil()/16, 2)
+	top := origin.Y.Ceil() + metrics.Descent.Ceil()/2
+	rect := image.Rect((origin.X + from).Floor(), top, (origin.X + to).Ceil(), top+thickness)
+	draw.Draw(dst, rect, image.NewUniform(c), image.Point{}, draw.Over)
+}
+
+func drawGlyphs(dst draw.Image, face font.Face, glyphs []placedGlyph, origin fixed.Point26_6, c color.Color) {
+	src := image.NewUniform(c)
+	for _, g := range glyphs {
+		dr, mask, maskp, _, ok := face.Glyph(fixed.Point26_6{X: origin.X + g.x, Y: origin.Y + g.dy}, g.r)
+		if !ok {
+			continue
+		}
+		draw.DrawMask(dst, dr, src, image.Point{}, mask, maskp, draw.Over)
+	}
+}
+
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// parseHexColor reads #RRGGBB, falling back for empty or malformed values.
+func parseHexColor(value string, fallback color.Color) color.Color {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "#")
+	if len(value) != 6 {
+		return fallback
+	}
+	v, err := strconv.ParseUint(value, 16, 32)
+	if err != nil {
+		return fallback
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xFF}
+}
+
+func withAlpha(c color.Color, alpha float64) color.Color {
+	r, g, b, _ := c.RGBA()
+	return color.NRGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: uint8(alpha * 255)}
+}
@@ -22,6 +22,20 @@ type RenderInput struct {
 	Mode               string
 	VideoConfig        config.VideoConfig
 	IncludeTranslation bool
+	// BackgroundCrop is an optional ffmpeg crop (w:h:x:y) applied to the background before scaling.
+	BackgroundCrop string
+	Inset          *Inset
+}
+
+// Inset places a secondary video as a picture-in-picture overlay.
+type Inset struct {
+	Path string
+	// Crop is an optional ffmpeg crop (w:h:x:y) applied before scaling.
+	Crop string
+	// Scale is the inset width as a fraction of the output width.
+	Scale    float64
+	Position string
+	Margin   int
 }
 
 func Render(ctx context.Context, input RenderInput) error {
@@ -64,6 +78,9 @@ func Render(ctx context.Context, input RenderInput) error {
 		args = append(args, "-stream_loop", "-1", "-i", input.BackgroundPath)
 	}
 	args = append(args, "-i", input.AudioPath)
+	if hasInset(input) {
+		args = append(args, "-i", input.Inset.Path)
+	}
 	args = append(args,
 		"-filter_complex", filters,
 		"-map", "[v]",
@@ -89,15 +106,28 @@ func buildFilters(input RenderInput, width, height int) (string, error) {
 	switch renderer {
 	case "ass", "subtitles":
 		return buildSubtitleFilters(input, width, height)
+	case "image":
+		return buildImageFilters(input, width, height)
 	default:
+		if mode, err := config.ParseDisplayMode(input.Mode); err == nil && mode.Kind == config.ModeKaraoke {
+			// drawtext cannot recolor one word of a line; overlay rendered
+			// cards, or let libass restyle the word when there is no font file
+			// to rasterize.
+			if input.VideoConfig.Font.File == "" {
+				return buildSubtitleFilters(input, width, height)
+			}
+			return buildImageFilters(input, width, height)
+		}
 		return buildDrawtextFilters(input, width, height)
 	}
 }
 
 func buildDrawtextFilters(input RenderInput, width, height int) (string, error) {
-	mode := strings.ToLower(input.Mode)
-	filters := []string{fmt.Sprintf("[0:v]scale=%d:%d:force_original_aspect_ratio=increase", width, height),
-		fmt.Sprintf("crop=%d:%d", width, height)}
+	mode, err := config.ParseDisplayMode(input.Mode)
+	if err != nil {
+		return "", err
+	}
+	filters := backgroundFilters(input, width, height)
 
 	fontSize := input.VideoConfig.Font.Size
 	if fontSize <= 0 {
@@ -119,29 +149,91 @@ func buildDrawtextFilters(input RenderInput, width, height int) (string, error)
 
 	textY := textYExpr(input.VideoConfig)
 
-	switch mode {
-	case "sequential", "repeat", "sequential-repeat":
+	switch mode.Kind {
+	case config.ModeSequential:
+		spacing := input.VideoConfig.TranslationSpacing
+		if spacing == 0 {
+			spacing = 24
+		}
 		for idx, t := range input.Timings {
-			arabicLines := wrapText(t.Verse.Text, maxWidth, fontSize)
-			arabicLines = maybeElongateLines(input.VideoConfig, arabicLines, maxWidth, fontSize)
-			textFile, err := writeTextFile(input.TempDir, fmt.Sprintf("ayah_%d.txt", idx), strings.Join(arabicLines, "\n"))
-			if err != nil {
-				return "", err
+			pages, size := fitVerse(t, input.VideoConfig, width, height, input.IncludeTranslation)
+			for pidx, page := range pages {
+				suffix := ""
+				if pidx > 0 {
+					suffix = fmt.Sprintf("_page_%d", pidx)
+				}
+				arabicLines := maybeElongateLines(input.VideoConfig, page.Lines, maxWidth, size)
+				textFile, err := writeTextFile(input.TempDir, fmt.Sprintf("ayah_%d%s.txt", idx, suffix), strings.Join(arabicLines, "\n"))
+				if err != nil {
+					return "", err
+				}
+				enable := fmt.Sprintf("between(t,%.3f,%.3f)", page.Start.Seconds(), page.End.Seconds())
+				fade := fadeAlphaExpr(input.VideoConfig, page.Start, page.End)
+				arabicY := textY
+				transY := fmt.Sprintf("%s+%d", textY, fontSize+spacing)
+				if fitted := size != fontSize || len(pages) > 1; fitted && page.Translation != "" {
+					// Center the fitted ayah and translation together.
+					arabicY = strings.ReplaceAll(textY, "text_h", fmt.Sprint(blockHeight(input.VideoConfig, maxWidth, page.Lines, page.Translation, size)))
+					transY = fmt.Sprintf("%s+%d", arabicY, len(arabicLines)*lineHeight(input.VideoConfig, size)+spacing)
+				}
+				filters = append(filters, DrawtextArgs(textFile, enable, input.VideoConfig, size, input.VideoConfig.Font.Color, arabicY, fade))
+				if page.Translation != "" {
+					transLines := wrapText(page.Translation, maxWidth, size/2, translationFontFile(input.VideoConfig))
+					transFile, err := writeTextFile(input.TempDir, fmt.Sprintf("translation_%d%s.txt", idx, suffix), strings.Join(transLines, "\n"))
+					if err != nil {
+						return "", err
+					}
+					filters = append(filters, DrawtextArgs(transFile, enable, input.VideoConfig, size/2, "#FFFFFF", transY, fade))
+				}
+				if input.VideoConfig.Reference.Enabled {
+					refText := fmt.Sprintf("%s • %d", t.Verse.SurahMeta.EnglishName, t.Verse.NumberInSurah)
+					refFile, err := writeTextFile(input.TempDir, fmt.Sprintf("ref_%d%s.txt", idx, suffix), refText)
+					if err != nil {
+						return "", err
+					}
+					filters = append(filters, DrawtextArgs(refFile, enable, input.VideoConfig, refSize, refColor, fmt.Sprintf("%s+%d", arabicY, refYOffset), fade))
+				}
 			}
-			enable := fmt.Sprintf("between(t,%.3f,%.3f)", t.Start.Seconds(), t.End.Seconds())
-			fade := fadeAlphaExpr(input.VideoConfig, t.Start, t.End)
-			filters = append(filters, DrawtextArgs(textFile, enable, input.VideoConfig, fontSize, input.VideoConfig.Font.Color, textY, fade))
-			if input.IncludeTranslation && t.Verse.Translation != "" {
-				transLines := wrapText(t.Verse.Translation, maxWidth, fontSize/2)
-				transFile, err := writeTextFile(input.TempDir, fmt.Sprintf("translation_%d.txt", idx), strings.Join(transLines, "\n"))
+		}
+	case config.ModeLineByLine:
+		spacing := input.VideoConfig.TranslationSpacing
+		if spacing == 0 {
+			spacing = 24
+		}
+		whole := strings.EqualFold(input.VideoConfig.LineByLine.Translation, "whole")
+		for idx, t := range input.Timings {
+			end := karaokeEnd(input.Timings, idx)
+			screens := buildLineScreens(t, end, maxWidth, fontSize, input.VideoConfig.Font.File, input.VideoConfig.LineByLine)
+			tallest := 1
+			for sidx, screen := range screens {
+				if screen.End <= screen.Start {
+					continue
+				}
+				lines := maybeElongateLines(input.VideoConfig, screen.Lines, maxWidth, fontSize)
+				textFile, err := writeTextFile(input.TempDir, fmt.Sprintf("ayah_%d_lines_%d.txt", idx, sidx), strings.Join(lines, "\n"))
 				if err != nil {
 					return "", err
 				}
-				spacing := input.VideoConfig.TranslationSpacing
-				if spacing == 0 {
-					spacing = 24
+				enable := fmt.Sprintf("between(t,%.3f,%.3f)", screen.Start.Seconds(), screen.End.Seconds())
+				fade := fadeAlphaExpr(input.VideoConfig, screen.Start, screen.End)
+				filters = append(filters, DrawtextArgs(textFile, enable, input.VideoConfig, fontSize, input.VideoConfig.Font.Color, textY, fade))
+				tallest = maxInt(tallest, len(lines))
+				if input.IncludeTranslation && !whole && screen.Translation != "" {
+					transFile, err := writeTextFile(input.TempDir, fmt.Sprintf("translation_%d_lines_%d.txt", idx, sidx), strings.Join(wrapText(screen.Translation, maxWidth, fontSize/2, translationFontFile(input.VideoConfig)), "\n"))
+					if err != nil {
+						return "", err
+					}
+					filters = append(filters, DrawtextArgs(transFile, enable, input.VideoConfig, fontSize/2, "#FFFFFF", fmt.Sprintf("%s+%d", textY, len(lines)*fontSize+spacing), fade))
+				}
+			}
+			enable := fmt.Sprintf("between(t,%.3f,%.3f)", t.Start.Seconds(), end.Seconds())
+			fade := fadeAlphaExpr(input.VideoConfig, t.Start, end)
+			if input.IncludeTranslation && whole && t.Verse.Translation != "" {
+				transFile, err := writeTextFile(input.TempDir, fmt.Sprintf("translation_%d.txt", idx), strings.Join(wrapText(t.Verse.Translation, maxWidth, fontSize/2, translationFontFile(input.VideoConfig)), "\n"))
+				if err != nil {
+					return "", err
 				}
-				filters = append(filters, DrawtextArgs(transFile, enable, input.VideoConfig, fontSize/2, "#FFFFFF", fmt.Sprintf("%s+%d", textY, fontSize+spacing), fade))
+				filters = append(filters, DrawtextArgs(transFile, enable, input.VideoConfig, fontSize/2, "#FFFFFF", fmt.Sprintf("%s+%d", textY, tallest*fontSize+spacing), fade))
 			}
 			if input.VideoConfig.Reference.Enabled {
 				refText := fmt.Sprintf("%s • %d", t.Verse.SurahMeta.EnglishName, t.Verse.NumberInSurah)
@@ -152,40 +244,64 @@ func buildDrawtextFilters(input RenderInput, width, height int) (string, error)
 				filters = append(filters, DrawtextArgs(refFile, enable, input.VideoConfig, refSize, refColor, fmt.Sprintf("%s+%d", textY, refYOffset), fade))
 			}
 		}
-	case "word-by-word", "word", "two-by-two", "two", "pair", "2x2", "repeat-2x2", "repeat-two-by-two", "repeat-pair":
+	case config.ModePhrase:
 		for idx, t := range input.Timings {
-			if mode == "two-by-two" || mode == "two" || mode == "pair" || mode == "2x2" || mode == "repeat-2x2" || mode == "repeat-two-by-two" || mode == "repeat-pair" {
-				pairs := buildWordPairs(t)
-				for widx, pair := range pairs {
-					if pair.End <= pair.Start || strings.TrimSpace(pair.Text) == "" {
-						continue
-					}
-					text := sanitizeText(pair.Text)
-					if input.VideoConfig.Elongate {
-						text = elongateText(text, input.VideoConfig.ElongateCount)
-					}
-					textFile, err := writeTextFile(input.TempDir, fmt.Sprintf("ayah_%d_pair_%d.txt", idx, widx), text)
-					if err != nil {
-						return "", err
-					}
-					enable := fmt.Sprintf("between(t,%.3f,%.3f)", pair.Start.Seconds(), pair.End.Seconds())
-					fade := fadeAlphaExpr(input.VideoConfig, pair.Start, pair.End)
-					filters = append(filters, DrawtextArgs(textFile, enable, input.VideoConfig, fontSize, input.VideoConfig.Font.Color, textY, fade))
+			for pidx, p := range buildPhrases(t, input.VideoConfig.Phrase) {
+				lines := wrapText(p.Text, maxWidth, fontSize, input.VideoConfig.Font.File)
+				lines = maybeElongateLines(input.VideoConfig, lines, maxWidth, fontSize)
+				textFile, err := writeTextFile(input.TempDir, fmt.Sprintf("ayah_%d_phrase_%d.txt", idx, pidx), strings.Join(lines, "\n"))
+				if err != nil {
+					return "", err
 				}
-			} else {
-				for widx, w := range t.WordTimings {
-					word := sanitizeText(w.Word)
-					if input.VideoConfig.Elongate {
-						word = elongateText(word, input.VideoConfig.ElongateCount)
-					}
-					textFile, err := writeTextFile(input.TempDir, fmt.Sprintf("ayah_%d_word_%d.txt", idx, widx), word)
-					if err != nil {
-						return "", err
-					}
-					enable := fmt.Sprintf("between(t,%.3f,%.3f)", w.Start.Seconds(), w.End.Seconds())
-					fade := fadeAlphaExpr(input.VideoConfig, w.Start, w.End)
-					filters = append(filters, DrawtextArgs(textFile, enable, input.VideoConfig, fontSize, input.VideoConfig.Font.Color, textY, fade))
+				enable := fmt.Sprintf("between(t,%.3f,%.3f)", p.Start.Seconds(), p.End.Seconds())
+				fade := fadeAlphaExpr(input.VideoConfig, p.Start, p.End)
+				filters = append(filters, DrawtextArgs(textFile, enable, input.VideoConfig, fontSize, input.VideoConfig.Font.Color, textY, fade))
+			}
+		}
+	case config.ModeProgressive:
+		for idx, t := range input.Timings {
+			arabicLines := wrapText(t.Verse.Text, maxWidth, fontSize, input.VideoConfig.Font.File)
+			arabicLines = maybeElongateLines(input.VideoConfig, arabicLines, maxWidth, fontSize)
+			reveal, err := buildProgressiveDrawtext(input, idx, arabicLines, fontSize, textY, karaokeEnd(input.Timings, idx))
+			if err != nil {
+				return "", err
+			}
+			filters = append(filters, reveal...)
+		}
+	case config.ModePrompter:
+		tp := newTeleprompter(input.Timings, input.VideoConfig, width, height, fontSize)
+		for lidx, line := range tp.lines {
+			textFile, err := writeTextFile(input.TempDir, fmt.Sprintf("prompter_%d.txt", lidx), sanitizeText(line.Text))
+			if err != nil {
+				return "", err
+			}
+			for _, span := range tp.spans(line) {
+				enable := fmt.Sprintf("between(t,%.3f,%.3f)", span.Start.Seconds(), span.End.Seconds())
+				color, alpha := promptStyle(input.VideoConfig, span.State)
+				filters = append(filters, DrawtextArgs(textFile, enable, input.VideoConfig, fontSize, color, "'"+tp.yExpr(line, span)+"'", alpha))
+			}
+		}
+	case config.ModeChunk:
+		for idx, t := range input.Timings {
+			for cidx, c := range displayChunks(t, mode, input.VideoConfig, maxWidth, fontSize) {
+				if c.End <= c.Start || strings.TrimSpace(c.Text) == "" {
+					continue
+				}
+				text := sanitizeText(c.Text)
+				if input.VideoConfig.Elongate {
+					text = elongateText(text, input.VideoConfig.ElongateCount)
+				}
+				textFile, err := writeTextFile(input.TempDir, fmt.Sprintf("ayah_%d_chunk_%d.txt", idx, cidx), text)
+				if err != nil {
+					return "", err
 				}
+				enable := fmt.Sprintf("between(t,%.3f,%.3f)", c.Start.Seconds(), c.End.Seconds())
+				fade := fadeAlphaExpr(input.VideoConfig, c.Start, c.End)
+				color := input.VideoConfig.Font.Color
+				if c.Color != "" {
+					color = c.Color
+				}
+				filters = append(filters, DrawtextArgs(textFile, enable, input.VideoConfig, fontSize, color, textY, fade))
 			}
 		}
 	default:
@@ -215,14 +331,64 @@ func buildSubtitleFilters(input RenderInput, width, height int) (string, error)
 	if input.VideoConfig.Font.File != "" {
 		fontsDir = filepath.Dir(input.VideoConfig.Font.File)
 	}
-	filters := []string{fmt.Sprintf("[0:v]scale=%d:%d:force_original_aspect_ratio=increase", width, height),
-		fmt.Sprintf("crop=%d:%d", width, height),
-		subtitlesFilter(assPath, fontsDir),
-	}
+	filters := backgroundFilters(input, width, height)
+	filters = append(filters, subtitlesFilter(assPath, fontsDir))
 	filters[len(filters)-1] = filters[len(filters)-1] + "[v]"
 	return strings.Join(filters, ","), nil
 }
 
+// backgroundFilters scales the background to the output size and overlays the inset, if any.
+func backgroundFilters(input RenderInput, width, height int) []string {
+	first := "[0:v]"
+	if crop := strings.TrimSpace(input.BackgroundCrop); crop != "" {
+		first += fmt.Sprintf("crop=%s,", crop)
+	}
+	first += fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=increase", width, height)
+	filters := []string{first, fmt.Sprintf("crop=%d:%d", width, height)}
+	if !hasInset(input) {
+		return filters
+	}
+	inset := input.Inset
+	scale := inset.Scale
+	if scale <= 0 || scale > 1 {
+		scale = 0.35
+	}
+	margin := inset.Margin
+	if margin <= 0 {
+		margin = 40
+	}
+	insetWidth := int(float64(width)*scale) / 2 * 2
+	insetChain := "[2:v]"
+	if crop := strings.TrimSpace(inset.Crop); crop != "" {
+		insetChain += fmt.Sprintf("crop=%s,", crop)
+	}
+	insetChain += fmt.Sprintf("scale=%d:-2", insetWidth)
+	x, y := insetPosition(inset.Position, margin)
+	filters[len(filters)-1] += fmt.Sprintf("[bg];%s[inset];[bg][inset]overlay=x=%s:y=%s", insetChain, x, y)
+	return filters
+}
+
+func hasInset(input RenderInput) bool {
+	return input.Inset != nil && strings.TrimSpace(input.Inset.Path) != ""
+}
+
+func insetPosition(position string, margin int) (string, string) {
+	left := fmt.Sprintf("%d", margin)
+	right := fmt.Sprintf("W-w-%d", margin)
+	top := fmt.Sprintf("%d", margin)
+	bottom := fmt.Sprintf("H-h-%d", margin)
+	switch strings.ToLower(position) {
+	case "top-left":
+		return left, top
+	case "bottom-left":
+		return left, bottom
+	case "bottom-right":
+		return right, bottom
+	default:
+		return right, top
+	}
+}
+
 func subtitlesFilter(assPath, fontsDir string) string {
 	args := []string{fmt.Sprintf("subtitles='%s'", escapeValue(assPath))}
 	if fontsDir != "" {
@@ -1,6 +1,7 @@
 package render
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -26,3 +27,21 @@ func TestFadeAlphaExprEnabled(t *testing.T) {
 		t.Fatalf("expected alpha expr")
 	}
 }
+
+func TestBackgroundFiltersWithCropAndInset(t *testing.T) {
+	input := RenderInput{
+		BackgroundCrop: "720:1280:0:0",
+		Inset:          &Inset{Path: "imam.mp4", Crop: "400:400:100:50", Scale: 0.3, Position: "bottom-left"},
+	}
+	filters := backgroundFilters(input, 1080, 1920)
+	joined := strings.Join(filters, ",")
+	if !strings.HasPrefix(joined, "[0:v]crop=720:1280:0:0,scale=1080:1920") {
+		t.Fatalf("expected background crop before scale, got %s", joined)
+	}
+	if !strings.Contains(joined, "[2:v]crop=400:400:100:50,scale=324:-2[inset]") {
+		t.Fatalf("expected inset chain, got %s", joined)
+	}
+	if !strings.HasSuffix(joined, "overlay=x=40:y=H-h-40") {
+		t.Fatalf("expected bottom-left overlay, got %s", joined)
+	}
+}
@@ -0,0 +1,227 @@
+package render
+
+import "unicode"
+
+// arabicForms lists the presentation forms of a letter as isolated, final,
+// initial and medial. Right-joining letters have no initial or medial form.
+var arabicForms = map[rune][4]rune{
+	'ء': {0xFE80},
+	'آ': {0xFE81, 0xFE82},
+	'أ': {0xFE83, 0xFE84},
+	'ؤ': {0xFE85, 0xFE86},
+	'إ': {0xFE87, 0xFE88},
+	'ئ': {0xFE89, 0xFE8A, 0xFE8B, 0xFE8C},
+	'ا': {0xFE8D, 0xFE8E},
+	'ب': {0xFE8F, 0xFE90, 0xFE91, 0xFE92},
+	'ة': {0xFE93, 0xFE94},
+	'ت': {0xFE95, 0xFE96, 0xFE97, 0xFE98},
+	'ث': {0xFE99, 0xFE9A, 0xFE9B, 0xFE9C},
+	'ج': {0xFE9D, 0xFE9E, 0xFE9F, 0xFEA0},
+	'ح': {0xFEA1, 0xFEA2, 0xFEA3, 0xFEA4},
+	'خ': {0xFEA5, 0xFEA6, 0xFEA7, 0xFEA8},
+	'د': {0xFEA9, 0xFEAA},
+	'ذ': {0xFEAB, 0xFEAC},
+	'ر': {0xFEAD, 0xFEAE},
+	'ز': {0xFEAF, 0xFEB0},
+	'س': {0xFEB1, 0xFEB2, 0xFEB3, 0xFEB4},
+	'ش': {0xFEB5, 0xFEB6, 0xFEB7, 0xFEB8},
+	'ص': {0xFEB9, 0xFEBA, 0xFEBB, 0xFEBC},
+	'ض': {0xFEBD, 0xFEBE, 0xFEBF, 0xFEC0},
+	'ط': {0xFEC1, 0xFEC2, 0xFEC3, 0xFEC4},
+	'ظ': {0xFEC5, 0xFEC6, 0xFEC7, 0xFEC8},
+	'ع': {0xFEC9, 0xFECA, 0xFECB, 0xFECC},
+	'غ': {0xFECD, 0xFECE, 0xFECF, 0xFED0},
+	'ف': {0xFED1, 0xFED2, 0xFED3, 0xFED4},
+	'ق': {0xFED5, 0xFED6, 0xFED7, 0xFED8},
+	'ك': {0xFED9, 0xFEDA, 0xFEDB, 0xFEDC},
+	'ل': {0xFEDD, 0xFEDE, 0xFEDF, 0xFEE0},
+	'م': {0xFEE1, 0xFEE2, 0xFEE3, 0xFEE4},
+	'ن': {0xFEE5, 0xFEE6, 0xFEE7, 0xFEE8},
+	'ه': {0xFEE9, 0xFEEA, 0xFEEB, 0xFEEC},
+	'و': {0xFEED, 0xFEEE},
+	'ى': {0xFEEF, 0xFEF0},
+	'ي': {0xFEF1, 0xFEF2, 0xFEF3, 0xFEF4},
+	'ٱ': {0xFB50, 0xFB51},
+	'پ': {0xFB56, 0xFB57, 0xFB58, 0xFB59},
+	'چ': {0xFB7A, 0xFB7B, 0xFB7C, 0xFB7D},
+	'ژ': {0xFB8A, 0xFB8B},
+	'ک': {0xFB8E, 0xFB8F, 0xFB90, 0xFB91},
+	'گ': {0xFB92, 0xFB93, 0xFB94, 0xFB95},
+	'ی': {0xFBFC, 0xFBFD, 0xFBFE, 0xFBFF},
+}
+
+// lamAlef maps the alef following a lam to the isolated ligature; the final
+// form is the next code point.
+var lamAlef = map[rune]rune{
+	'آ': 0xFEF5,
+	'أ': 0xFEF7,
+	'إ': 0xFEF9,
+	'ا': 0xFEFB,
+}
+
+const (
+	formIsolated = iota
+	formFinal
+	formInitial
+	formMedial
+)
+
+// shapedCluster is one base character with the combining marks drawn on it.
+// Base is the presentation form; Plain is the letter to fall back to when the
+// font has no glyph for the form.
+type shapedCluster struct {
+	Base  rune
+	Plain rune
+	Marks []rune
+}
+
+// shapeArabic picks contextual forms and lam-alef ligatures and returns the
+// clusters in visual (left-to-right) order. Runs of digits and Latin text keep
+// their reading order inside the right-to-left line.
+func shapeArabic(text string) []shapedCluster {
+	return visualOrder(joinArabic([]rune(sanitizeText(text))))
+}
+
+func joinArabic(runes []rune) []shapedCluster {
+	var out []shapedCluster
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if isCombining(r) {
+			if len(out) == 0 {
+				out = append(out, shapedCluster{Base: r, Plain: r})
+				continue
+			}
+			out[len(out)-1].Marks = append(out[len(out)-1].Marks, r)
+			continue
+		}
+		prev := neighbour(runes, i, -1)
+		joinsPrev := joinsForward(prev) && joinsBackward(r)
+		if r == 'ل' {
+			if next := neighbourIndex(runes, i, 1); next >= 0 {
+				if lig, ok := lamAlef[runes[next]]; ok {
+					cluster := shapedCluster{Base: lig, Plain: r}
+					if joinsPrev {
+						cluster.Base = lig + 1
+					}
+					// Marks on the lam and on the alef both sit on the ligature.
+					for j := i + 1; j < len(runes) && (j <= next || isCombining(runes[j])); j++ {
+						if j != next {
+							cluster.Marks = append(cluster.Marks, runes[j])
+						}
+						i = j
+					}
+					out = append(out, cluster)
+					continue
+				}
+			}
+		}
+		cluster := shapedCluster{Base: r, Plain: r}
+		if forms, ok := arabicForms[r]; ok {
+			joinsNext := joinsForward(r) && joinsBackward(neighbour(runes, i, 1))
+			form := formIsolated
+			switch {
+			case joinsPrev && joinsNext:
+				form = formMedial
+			case joinsPrev:
+				form = formFinal
+			case joinsNext:
+				form = formInitial
+			}
+			if forms[form] != 0 {
+				cluster.Base = forms[form]
+			}
+		}
+		out = append(out, cluster)
+	}
+	return out
+}
+
+// neighbour returns the nearest non-mark rune before (dir -1) or after (dir 1) i.
+func neighbour(runes []rune, i, dir int) rune {
+	if j := neighbourIndex(runes, i, dir); j >= 0 {
+		return runes[j]
+	}
+	return 0
+}
+
+func neighbourIndex(runes []rune, i, dir int) int {
+	for j := i + dir; j >= 0 && j < len(runes); j += dir {
+		if !isCombining(runes[j]) {
+			return j
+		}
+	}
+	return -1
+}
+
+// joinsForward reports whether r connects to the letter after it.
+func joinsForward(r rune) bool {
+	if r == 'ـ' {
+		return true
+	}
+	forms, ok := arabicForms[r]
+	return ok && forms[formInitial] != 0
+}
+
+// joinsBackward reports whether r connects to the letter before it.
+func joinsBackward(r rune) bool {
+	if r == 'ـ' {
+		return true
+	}
+	forms, ok := arabicForms[r]
+	return ok && forms[formFinal] != 0
+}
+
+// visualOrder reverses the right-to-left line, keeping left-to-right runs
+// (digits, Latin letters) in reading order.
+func visualOrder(clusters []shapedCluster) []shapedCluster {
+	out := make([]shapedCluster, 0, len(clusters))
+	for end := len(clusters); end > 0; {
+		start := end - 1
+		if isLeftToRight(clusters[start].Plain) {
+			for start > 0 && isLeftToRight(clusters[start-1].Plain) {
+				start--
+			}
+			out = append(out, clusters[start:end]...)
+		} else {
+			out = append(out, mirrored(clusters[start]))
+		}
+		end = start
+	}
+	return out
+}
+
+func isLeftToRight(r rune) bool {
+	return unicode.IsDigit(r) || (unicode.IsLetter(r) && unicode.In(r, unicode.Latin))
+}
+
+// mirroredPairs are brackets that point the other way in right-to-left text.
+// Ornate Quranic parentheses are drawn for right-to-left already.
+var mirroredPairs = map[rune]rune{'(': ')', ')': '(', '[': ']', ']': '[', '{': '}', '}': '{', '«': '»', '»': '«'}
+
+func mirrored(c shapedCluster) shapedCluster {
+	if m, ok := mirroredPairs[c.Base]; ok {
+		c.Base, c.Plain = m, m
+	}
+	return c
+}
+
+// wordSpan returns the cluster range of the n-th logical word of a shaped
+// line. Words run right to left, so the first word is the last space-separated
+// group.
+func wordSpan(clusters []shapedCluster, n int) [2]int {
+	var groups [][2]int
+	start := 0
+	for i := 0; i <= len(clusters); i++ {
+		if i == len(clusters) || clusters[i].Plain == ' ' {
+			if i > start {
+				groups = append(groups, [2]int{start, i})
+			}
+			start = i + 1
+		}
+	}
+	k := len(groups) - 1 - n
+	if n < 0 || k < 0 {
+		return [2]int{}
+	}
+	return groups[k]
+}
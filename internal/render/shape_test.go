@@ -0,0 +1,93 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/image/font/gofont/goregular"
+
+	"qgencodex/internal/config"
+	"qgencodex/internal/quran"
+)
+
+func baseRunes(clusters []shapedCluster) []rune {
+	out := make([]rune, len(clusters))
+	for i, c := range clusters {
+		out[i] = c.Base
+	}
+	return out
+}
+
+func TestShapeArabicJoiningForms(t *testing.T) {
+	// بسم: initial beh, medial seen, final meem; shown right to left.
+	got := baseRunes(shapeArabic("بسم"))
+	want := []rune{0xFEE2, 0xFEB4, 0xFE91}
+	if string(got) != string(want) {
+		t.Fatalf("expected %U, got %U", want, got)
+	}
+	// دار: dal and alef do not join forward, so every letter is isolated.
+	got = baseRunes(shapeArabic("دار"))
+	want = []rune{0xFEAD, 0xFE8D, 0xFEA9}
+	if string(got) != string(want) {
+		t.Fatalf("expected %U, got %U", want, got)
+	}
+}
+
+func TestShapeArabicLamAlefAndMarks(t *testing.T) {
+	clusters := shapeArabic("قَالَ لَا")
+	// Visual order: لا ligature, space, isolated lam (alef does not join
+	// forward), final alef, initial qaf.
+	got := baseRunes(clusters)
+	want := []rune{0xFEFB, ' ', 0xFEDD, 0xFE8E, 0xFED7}
+	if string(got) != string(want) {
+		t.Fatalf("expected %U, got %U", want, got)
+	}
+	if len(clusters[0].Marks) != 1 || clusters[0].Marks[0] != 'َ' || len(clusters[4].Marks) != 1 {
+		t.Fatalf("expected fatha on the ligature and the qaf, got %+v", clusters)
+	}
+	if got := baseRunes(shapeArabic("فلا")); got[0] != 0xFEFC {
+		t.Fatalf("expected final lam-alef after a joining letter, got %U", got)
+	}
+}
+
+func TestShapeArabicKeepsDigitsInOrder(t *testing.T) {
+	got := baseRunes(shapeArabic("آية 12"))
+	if string(got[:2]) != "12" {
+		t.Fatalf("expected digits first and in order, got %U", got)
+	}
+}
+
+func TestBuildImageFilters(t *testing.T) {
+	dir := t.TempDir()
+	fontPath := filepath.Join(dir, "Go-Regular.ttf")
+	if err := os.WriteFile(fontPath, goregular.TTF, 0o644); err != nil {
+		t.Fatalf("write font: %v", err)
+	}
+	cfg := config.Default().Video
+	cfg.Font.File = fontPath
+	cfg.Font.OutlineWidth = 2
+	cfg.Glass.Enabled = true
+	input := RenderInput{
+		Timings: []Timing{
+			{Verse: quran.Verse{Text: "Qul huwa"}, End: 1 * time.Second},
+			{Verse: quran.Verse{Text: "Allahu ahad"}, Start: 1 * time.Second, End: 2 * time.Second},
+		},
+		TempDir:     dir,
+		Mode:        "sequential",
+		VideoConfig: cfg,
+	}
+	filters, err := buildImageFilters(input, 1080, 1920)
+	if err != nil {
+		t.Fatalf("buildImageFilters failed: %v", err)
+	}
+	if strings.Count(filters, "movie=") != 2 || !strings.Contains(filters, "enable='between(t,1.000,2.000)'[v]") {
+		t.Fatalf("unexpected filters: %s", filters)
+	}
+	info, err := os.Stat(filepath.Join(dir, "card_ayah_0.png"))
+	if err != nil || info.Size() == 0 {
+		t.Fatalf("expected a rasterized card, got %v", err)
+	}
+}
@@ -0,0 +1,222 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"time"
+
+	"qgencodex/internal/config"
+)
+
+// Teleprompter line states.
+const (
+	promptUpcoming = iota
+	promptCurrent
+	promptPast
+)
+
+// promptLine is one wrapped line of the passage, laid out top to bottom.
+type promptLine struct {
+	Text string
+	Ayah int
+	// Y is the top of the line in passage coordinates.
+	Y     float64
+	Start time.Duration
+}
+
+// promptSpan is a stretch of time a line is on screen in one state.
+type promptSpan struct {
+	Start time.Duration
+	End   time.Duration
+	State int
+}
+
+// scrollKey pins the passage scroll offset Y at time T; the scroll is linear
+// between keys.
+type scrollKey struct {
+	T time.Duration
+	Y float64
+}
+
+// teleprompter lays out the whole passage up front and scrolls it so each
+// line reaches the focus band when its first word is recited.
+type teleprompter struct {
+	lines      []promptLine
+	keys       []scrollKey
+	ayahs      [][2]time.Duration
+	lineHeight float64
+	// top is the screen y of a line's top while it is in focus.
+	top    float64
+	height float64
+	end    time.Duration
+}
+
+func newTeleprompter(timings []Timing, cfg config.VideoConfig, width, height, fontSize int) teleprompter {
+	focus := cfg.Teleprompter.Focus
+	if focus <= 0 {
+		focus = 0.4
+	}
+	lh := float64(lineHeight(cfg, fontSize))
+	tp := teleprompter{lineHeight: lh, top: float64(height)*focus - lh/2, height: float64(height)}
+	maxWidth := maxTextWidth(cfg, width)
+	y := 0.0
+	for i, t := range timings {
+		end := karaokeEnd(timings, i)
+		tp.ayahs = append(tp.ayahs, [2]time.Duration{t.Start, end})
+		if t.End > tp.end {
+			tp.end = t.End
+		}
+		starts := wordStarts(t, end)
+		lines := wrapText(t.Verse.Text, maxWidth, fontSize, cfg.Font.File)
+		offsets := lineWordOffsets(t.Verse.Text, lines)
+		for l, line := range lines {
+			start := t.Start
+			if word := offsets[l]; word > 0 && word < len(starts) {
+				start = starts[word]
+			}
+			tp.lines = append(tp.lines, promptLine{Text: line, Ayah: i, Y: y, Start: start})
+			if len(tp.keys) == 0 || start > tp.keys[len(tp.keys)-1].T {
+				tp.keys = append(tp.keys, scrollKey{T: start, Y: y})
+			}
+			y += lh
+		}
+		// A little extra space between ayahs, as on a mushaf page.
+		y += lh / 3
+	}
+	return tp
+}
+
+// scrollAt is the passage offset at the focus band at time t.
+func (tp teleprompter) scrollAt(t time.Duration) float64 {
+	if len(tp.keys) == 0 {
+		return 0
+	}
+	if t <= tp.keys[0].T {
+		return tp.keys[0].Y
+	}
+	for i := 0; i+1 < len(tp.keys); i++ {
+		a, b := tp.keys[i], tp.keys[i+1]
+		if t < b.T {
+			return a.Y + (b.Y-a.Y)*float64(t-a.T)/float64(b.T-a.T)
+		}
+	}
+	return tp.keys[len(tp.keys)-1].Y
+}
+
+// timeAt is when the scroll first reaches y, or the end if it never does.
+func (tp teleprompter) timeAt(y float64) time.Duration {
+	if len(tp.keys) == 0 || y <= tp.keys[0].Y {
+		return 0
+	}
+	for i := 0; i+1 < len(tp.keys); i++ {
+		a, b := tp.keys[i], tp.keys[i+1]
+		if y <= b.Y {
+			return a.T + time.Duration(float64(b.T-a.T)*(y-a.Y)/(b.Y-a.Y))
+		}
+	}
+	return tp.end
+}
+
+// screenY is the screen y of the line's top at time t.
+func (tp teleprompter) screenY(line promptLine, t time.Duration) float64 {
+	return tp.top + line.Y - tp.scrollAt(t)
+}
+
+// spans splits the time the line is on screen by the state of its ayah.
+func (tp teleprompter) spans(line promptLine) []promptSpan {
+	from := tp.timeAt(tp.top + line.Y - tp.height)
+	to := tp.timeAt(tp.top + line.Y + tp.lineHeight)
+	if to <= from {
+		to = tp.end
+	}
+	ayah := tp.ayahs[line.Ayah]
+	var spans []promptSpan
+	for state, span := range [][2]time.Duration{{from, ayah[0]}, {ayah[0], ayah[1]}, {ayah[1], to}} {
+		start, end := maxDuration(span[0], from), minDuration(span[1], to)
+		if end > start {
+			spans = append(spans, promptSpan{Start: start, End: end, State: state})
+		}
+	}
+	return spans
+}
+
+// breaks returns the span boundaries and the scroll keys inside it, where
+// the scroll speed changes.
+func (tp teleprompter) breaks(span promptSpan) []time.Duration {
+	out := []time.Duration{span.Start}
+	for _, k := range tp.keys {
+		if k.T > span.Start && k.T < span.End {
+			out = append(out, k.T)
+		}
+	}
+	return append(out, span.End)
+}
+
+// yExpr is an ffmpeg expression of t for the line's top during span.
+func (tp teleprompter) yExpr(line promptLine, span promptSpan) string {
+	var segs []int
+	for i := 0; i+1 < len(tp.keys); i++ {
+		if tp.keys[i].T < span.End && tp.keys[i+1].T > span.Start {
+			segs = append(segs, i)
+		}
+	}
+	base := tp.top + line.Y
+	if len(segs) == 0 {
+		return fmt.Sprintf("%.1f", base-tp.scrollAt(span.Start))
+	}
+	expr := fmt.Sprintf("%.1f", tp.keys[segs[len(segs)-1]+1].Y)
+	for k := len(segs) - 1; k >= 0; k-- {
+		a, b := tp.keys[segs[k]], tp.keys[segs[k]+1]
+		slope := (b.Y - a.Y) / (b.T - a.T).Seconds()
+		expr = fmt.Sprintf("if(lt(t,%.3f),%.1f+(t-%.3f)*%.3f,%s)", b.T.Seconds(), a.Y, a.T.Seconds(), slope, expr)
+	}
+	if first := tp.keys[segs[0]]; first.T > span.Start {
+		expr = fmt.Sprintf("if(lt(t,%.3f),%.1f,%s)", first.T.Seconds(), first.Y, expr)
+	}
+	return fmt.Sprintf("%.1f-(%s)", base, expr)
+}
+
+// promptStyle returns the drawtext font color and alpha of a line state.
+func promptStyle(cfg config.VideoConfig, state int) (string, string) {
+	switch state {
+	case promptCurrent:
+		if cfg.Teleprompter.HighlightColor != "" {
+			return cfg.Teleprompter.HighlightColor, ""
+		}
+	case promptPast:
+		return cfg.Font.Color, fmt.Sprintf("%.2f", cfg.Teleprompter.DimOpacity)
+	}
+	return cfg.Font.Color, ""
+}
+
+// assPromptStyle is the ASS override of a line state.
+func assPromptStyle(cfg config.VideoConfig, state int) string {
+	switch state {
+	case promptCurrent:
+		return fmt.Sprintf("{\\c%s&}", assColor(cfg.Teleprompter.HighlightColor, "#FFD700"))
+	case promptPast:
+		return fmt.Sprintf("{\\alpha&H%02X&}", int((1-cfg.Teleprompter.DimOpacity)*255+0.5))
+	}
+	return ""
+}
+
+// dimImage scales the opacity of a premultiplied card image.
+func dimImage(img *image.RGBA, opacity float64) {
+	for i := range img.Pix {
+		img.Pix[i] = uint8(float64(img.Pix[i])*opacity + 0.5)
+	}
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
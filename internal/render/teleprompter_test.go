@@ -0,0 +1,85 @@
+package render
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"qgencodex/internal/config"
+	"qgencodex/internal/quran"
+)
+
+func prompterTimings() []Timing {
+	return []Timing{
+		{Verse: quran.Verse{Text: "aaaa bbbb"}, End: 2 * time.Second},
+		{Verse: quran.Verse{Text: "cccc dddd"}, Start: 2 * time.Second, End: 4 * time.Second},
+	}
+}
+
+func prompterConfig() config.VideoConfig {
+	cfg := config.Default().Video
+	cfg.Font.Size = 40
+	cfg.LineSpacing = 10
+	return cfg
+}
+
+func TestTeleprompterScroll(t *testing.T) {
+	tp := newTeleprompter(prompterTimings(), prompterConfig(), 1080, 1920, 40)
+	if len(tp.lines) != 2 || len(tp.keys) != 2 {
+		t.Fatalf("expected one line and one scroll key per ayah, got %+v / %+v", tp.lines, tp.keys)
+	}
+	// 40*3/2+10 per line plus a third of a line between ayahs.
+	second := 70 + 70.0/3
+	if tp.lines[1].Y != second || tp.keys[1] != (scrollKey{T: 2 * time.Second, Y: second}) {
+		t.Fatalf("expected the second ayah at %.2f, got %+v", second, tp.keys)
+	}
+	if got := tp.scrollAt(time.Second); got != second/2 {
+		t.Fatalf("expected the scroll halfway at 1s, got %.2f", got)
+	}
+	if got := tp.timeAt(second / 2); got != time.Second {
+		t.Fatalf("expected timeAt to invert scrollAt, got %s", got)
+	}
+	if tp.screenY(tp.lines[1], 2*time.Second) != tp.top {
+		t.Fatalf("expected the second ayah in the focus band when it starts")
+	}
+	spans := tp.spans(tp.lines[0])
+	want := []promptSpan{{Start: 0, End: 2 * time.Second, State: promptCurrent}, {Start: 2 * time.Second, End: 4 * time.Second, State: promptPast}}
+	if len(spans) != 2 || spans[0] != want[0] || spans[1] != want[1] {
+		t.Fatalf("expected the first ayah current then dimmed, got %+v", spans)
+	}
+}
+
+func TestDrawtextTeleprompter(t *testing.T) {
+	cfg := prompterConfig()
+	input := RenderInput{Timings: prompterTimings(), TempDir: t.TempDir(), Mode: "mushaf-flow", VideoConfig: cfg}
+	filters, err := buildDrawtextFilters(input, 1080, 1920)
+	if err != nil {
+		t.Fatalf("buildDrawtextFilters failed: %v", err)
+	}
+	if strings.Count(filters, "drawtext=") != 4 {
+		t.Fatalf("expected two states per line: %s", filters)
+	}
+	if !strings.Contains(filters, "y='733.0-(if(lt(t,2.000),0.0+(t-0.000)*46.667,93.3))'") {
+		t.Fatalf("expected a scrolling y expression: %s", filters)
+	}
+	if !strings.Contains(filters, "fontcolor=#FFD700") || !strings.Contains(filters, "alpha=0.45") {
+		t.Fatalf("expected the current ayah highlighted and the past one dimmed: %s", filters)
+	}
+}
+
+func TestASSTeleprompterMoves(t *testing.T) {
+	opts := assOptions{Width: 1080, Height: 1920, Mode: "teleprompter", Timings: prompterTimings(), Config: prompterConfig()}
+	lines, err := buildASSLines(opts, 40)
+	if err != nil {
+		t.Fatalf("buildASSLines failed: %v", err)
+	}
+	if len(lines) != 4 {
+		t.Fatalf("expected two events per line, got %q", lines)
+	}
+	if !strings.Contains(lines[0], `{\an8\move(540,733,540,640)}{\c&H0000D7FF&}aaaa bbbb`) {
+		t.Fatalf("expected the first ayah to scroll up highlighted, got %q", lines[0])
+	}
+	if !strings.Contains(lines[1], `{\an8\pos(540,640)}{\alpha&H8C&}aaaa bbbb`) {
+		t.Fatalf("expected the first ayah dimmed once recited, got %q", lines[1])
+	}
+}
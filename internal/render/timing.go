@@ -20,6 +20,15 @@ type WordTiming struct {
 	Word  string
 	Start time.Duration
 	End   time.Duration
+	// Source is how the timing was obtained: matched, interpolated, index or estimated.
+	Source string
+	// Confidence is the 0-1 alignment confidence; estimated words have 0.
+	Confidence float64
+	// Probability is the recognizer's probability for the word as heard, 0
+	// when unknown; Confidence folds it with Source.
+	Probability float64
+	// Color overrides the font color in word-by-word mode, e.g. to highlight mistakes.
+	Color string
 }
 
 // BuildTimings maps verses and audio segments to timeline timings.
@@ -34,25 +43,11 @@ func BuildTimings(verses []quran.Verse, segments []audio.Segment) ([]Timing, err
 		start := cursor
 		end := cursor + seg.Duration
 		cursor = end
-		words := strings.Fields(verse.Text)
-		var wordTimings []WordTiming
-		if len(words) > 0 {
-			perWord := seg.Duration / time.Duration(len(words))
-			wc := start
-			for idx, w := range words {
-				we := wc + perWord
-				if idx == len(words)-1 {
-					we = end
-				}
-				wordTimings = append(wordTimings, WordTiming{Word: w, Start: wc, End: we})
-				wc = we
-			}
-		}
 		timings[i] = Timing{
 			Verse:       verse,
 			Start:       start,
 			End:         end,
-			WordTimings: wordTimings,
+			WordTimings: SplitWordTimings(strings.Fields(verse.Text), start, end),
 		}
 	}
 	return timings, nil
@@ -0,0 +1,253 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"qgencodex/internal/quran"
+	"qgencodex/internal/utils"
+)
+
+const timingFileVersion = 1
+
+// TimingFile is the editable JSON form of a render's timings. Times are in
+// seconds; each entry is one on-screen segment of an ayah, so split or
+// repeated ayahs appear as several entries.
+type TimingFile struct {
+	Version int           `json:"version"`
+	Timings []TimingEntry `json:"timings"`
+}
+
+type TimingEntry struct {
+	Surah       int         `json:"surah"`
+	Ayah        int         `json:"ayah"`
+	Text        string      `json:"text"`
+	Translation string      `json:"translation,omitempty"`
+	Start       float64     `json:"start"`
+	End         float64     `json:"end"`
+	Words       []WordEntry `json:"words,omitempty"`
+}
+
+type WordEntry struct {
+	Word        string  `json:"word"`
+	Start       float64 `json:"start"`
+	End         float64 `json:"end"`
+	Source      string  `json:"source,omitempty"`
+	Confidence  float64 `json:"confidence,omitempty"`
+	Probability float64 `json:"probability,omitempty"`
+}
+
+// NewTimingFile converts timings to their file form.
+func NewTimingFile(timings []Timing) TimingFile {
+	f := TimingFile{Version: timingFileVersion, Timings: make([]TimingEntry, 0, len(timings))}
+	for _, t := range timings {
+		entry := TimingEntry{
+			Surah:       t.Verse.SurahMeta.Number,
+			Ayah:        t.Verse.NumberInSurah,
+			Text:        t.Verse.Text,
+			Translation: t.Verse.Translation,
+			Start:       toSeconds(t.Start),
+			End:         toSeconds(t.End),
+		}
+		for _, wt := range t.WordTimings {
+			entry.Words = append(entry.Words, WordEntry{
+				Word:        wt.Word,
+				Start:       toSeconds(wt.Start),
+				End:         toSeconds(wt.End),
+				Source:      wt.Source,
+				Confidence:  math.Round(wt.Confidence*1000) / 1000,
+				Probability: math.Round(wt.Probability*1000) / 1000,
+			})
+		}
+		f.Timings = append(f.Timings, entry)
+	}
+	return f
+}
+
+// WriteTimingFile writes timings as indented JSON.
+func WriteTimingFile(path string, timings []Timing) error {
+	if err := utils.EnsureDir(filepath.Dir(path)); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(NewTimingFile(timings), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// LoadTimingFile reads and structurally validates a timing file.
+func LoadTimingFile(path string) (TimingFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return TimingFile{}, err
+	}
+	var f TimingFile
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&f); err != nil {
+		return TimingFile{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := f.Validate(); err != nil {
+		return TimingFile{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Validate checks versions, references and time ranges.
+func (f TimingFile) Validate() error {
+	if f.Version != timingFileVersion {
+		return fmt.Errorf("unsupported timing file version %d", f.Version)
+	}
+	if len(f.Timings) == 0 {
+		return errors.New("no timings")
+	}
+	for i, e := range f.Timings {
+		if e.Surah < 1 || e.Surah > 114 || e.Ayah < 1 {
+			return fmt.Errorf("timings[%d]: invalid reference %d:%d", i, e.Surah, e.Ayah)
+		}
+		if e.Start < 0 || e.End < e.Start {
+			return fmt.Errorf("timings[%d] (%d:%d): invalid range %.3f-%.3f", i, e.Surah, e.Ayah, e.Start, e.End)
+		}
+		if len(e.Words) > len(strings.Fields(e.Text)) {
+			return fmt.Errorf("timings[%d] (%d:%d): %d word timings for %d words", i, e.Surah, e.Ayah, len(e.Words), len(strings.Fields(e.Text)))
+		}
+		prev := -1.0
+		for j, w := range e.Words {
+			if w.Start < 0 || w.End < w.Start {
+				return fmt.Errorf("timings[%d].words[%d] %q: invalid range %.3f-%.3f", i, j, w.Word, w.Start, w.End)
+			}
+			if w.Start < prev {
+				return fmt.Errorf("timings[%d].words[%d] %q: starts before the previous word", i, j, w.Word)
+			}
+			prev = w.Start
+		}
+	}
+	return nil
+}
+
+// Range returns the surah and ayah span covered by the file.
+func (f TimingFile) Range() (surah, start, end int, err error) {
+	for _, e := range f.Timings {
+		if surah == 0 {
+			surah, start, end = e.Surah, e.Ayah, e.Ayah
+			continue
+		}
+		if e.Surah != surah {
+			return 0, 0, 0, fmt.Errorf("timing file spans surahs %d and %d", surah, e.Surah)
+		}
+		if e.Ayah < start {
+			start = e.Ayah
+		}
+		if e.Ayah > end {
+			end = e.Ayah
+		}
+	}
+	return surah, start, end, nil
+}
+
+// AyahRange is a contiguous run of ayahs in one surah.
+type AyahRange struct {
+	Surah int
+	Start int
+	End   int
+}
+
+// Ranges splits the file into passages: a new range starts when the surah
+// changes or the ayahs jump by more than one.
+func (f TimingFile) Ranges() []AyahRange {
+	var ranges []AyahRange
+	for _, e := range f.Timings {
+		if n := len(ranges) - 1; n >= 0 && ranges[n].Surah == e.Surah && e.Ayah >= ranges[n].Start-1 && e.Ayah <= ranges[n].End+1 {
+			if e.Ayah < ranges[n].Start {
+				ranges[n].Start = e.Ayah
+			}
+			if e.Ayah > ranges[n].End {
+				ranges[n].End = e.Ayah
+			}
+			continue
+		}
+		ranges = append(ranges, AyahRange{Surah: e.Surah, Start: e.Ayah, End: e.Ayah})
+	}
+	return ranges
+}
+
+// Apply builds render timings from the file, checking every entry's text and
+// words against the fetched verses.
+func (f TimingFile) Apply(verses []quran.Verse) ([]Timing, error) {
+	byRef := make(map[[2]int]quran.Verse, len(verses))
+	for _, v := range verses {
+		byRef[[2]int{v.SurahMeta.Number, v.NumberInSurah}] = v
+	}
+	out := make([]Timing, 0, len(f.Timings))
+	for i, e := range f.Timings {
+		verse, ok := byRef[[2]int{e.Surah, e.Ayah}]
+		if !ok {
+			return nil, fmt.Errorf("timings[%d]: ayah %d:%d is not in the requested range", i, e.Surah, e.Ayah)
+		}
+		words := strings.Fields(e.Text)
+		if !containsRun(strings.Fields(verse.Text), words) {
+			return nil, fmt.Errorf("timings[%d]: text does not match ayah %d:%d", i, e.Surah, e.Ayah)
+		}
+		// Word timings may cover only part of the text (repeated fragments).
+		timed := make([]string, len(e.Words))
+		for j, w := range e.Words {
+			timed[j] = w.Word
+		}
+		if len(timed) > 0 && !containsRun(words, timed) {
+			return nil, fmt.Errorf("timings[%d]: word timings do not match the text of ayah %d:%d", i, e.Surah, e.Ayah)
+		}
+		verse.Text = strings.Join(words, " ")
+		if e.Translation != "" {
+			verse.Translation = e.Translation
+		}
+		t := Timing{Verse: verse, Start: fromSeconds(e.Start), End: fromSeconds(e.End)}
+		for _, w := range e.Words {
+			t.WordTimings = append(t.WordTimings, WordTiming{
+				Word:        w.Word,
+				Start:       fromSeconds(w.Start),
+				End:         fromSeconds(w.End),
+				Source:      w.Source,
+				Confidence:  w.Confidence,
+				Probability: w.Probability,
+			})
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+// containsRun reports whether part appears as a contiguous run in words.
+func containsRun(words, part []string) bool {
+	if len(part) == 0 {
+		return false
+	}
+	for i := 0; i+len(part) <= len(words); i++ {
+		match := true
+		for j := range part {
+			if words[i+j] != part[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+func toSeconds(d time.Duration) float64 {
+	return math.Round(d.Seconds()*1000) / 1000
+}
+
+func fromSeconds(sec float64) time.Duration {
+	return time.Duration(math.Round(sec*1000)) * time.Millisecond
+}
@@ -0,0 +1,98 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"qgencodex/internal/quran"
+)
+
+func testVerses() []quran.Verse {
+	meta := quran.SurahMeta{Number: 112}
+	return []quran.Verse{
+		{NumberInSurah: 1, Text: "قُلْ هُوَ ٱللَّهُ أَحَدٌ", SurahMeta: meta},
+		{NumberInSurah: 2, Text: "ٱللَّهُ ٱلصَّمَدُ", SurahMeta: meta},
+	}
+}
+
+func TestTimingFileRoundTrip(t *testing.T) {
+	verses := testVerses()
+	first := verses[0]
+	first.Text = "قُلْ هُوَ"
+	timings := []Timing{
+		{Verse: first, Start: 0, End: 1200 * time.Millisecond, WordTimings: []WordTiming{
+			{Word: "قُلْ", Start: 0, End: 500 * time.Millisecond, Source: "matched", Confidence: 0.91, Probability: 0.82},
+			{Word: "هُوَ", Start: 500 * time.Millisecond, End: 1200 * time.Millisecond, Source: "interpolated"},
+		}},
+		{Verse: verses[1], Start: 1500 * time.Millisecond, End: 3 * time.Second},
+	}
+	path := filepath.Join(t.TempDir(), "timings.json")
+	if err := WriteTimingFile(path, timings); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	tf, err := LoadTimingFile(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if s, a, b, err := tf.Range(); err != nil || s != 112 || a != 1 || b != 2 {
+		t.Fatalf("unexpected range %d %d-%d (%v)", s, a, b, err)
+	}
+	loaded, err := tf.Apply(verses)
+	if err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	if len(loaded) != 2 || loaded[0].Verse.Text != "قُلْ هُوَ" || loaded[1].Start != 1500*time.Millisecond {
+		t.Fatalf("unexpected timings: %+v", loaded)
+	}
+	if got := loaded[0].WordTimings[0]; got.End != 500*time.Millisecond || got.Source != "matched" || got.Confidence != 0.91 || got.Probability != 0.82 {
+		t.Fatalf("unexpected word timing: %+v", got)
+	}
+}
+
+func TestTimingFileRejectsMismatchedText(t *testing.T) {
+	cases := map[string]string{
+		"text":  `{"version":1,"timings":[{"surah":112,"ayah":2,"text":"ٱللَّهُ أَحَدٌ","start":0,"end":1}]}`,
+		"words": `{"version":1,"timings":[{"surah":112,"ayah":2,"text":"ٱللَّهُ ٱلصَّمَدُ","start":0,"end":1,"words":[{"word":"ٱلصَّمَدُ","start":0,"end":0.5},{"word":"ٱللَّهُ","start":0.5,"end":1}]}]}`,
+		"range": `{"version":1,"timings":[{"surah":112,"ayah":4,"text":"ٱللَّهُ","start":0,"end":1}]}`,
+		"times": `{"version":1,"timings":[{"surah":112,"ayah":2,"text":"ٱللَّهُ","start":2,"end":1}]}`,
+		"field": `{"version":1,"timings":[{"surah":112,"ayah":2,"txt":"ٱللَّهُ","start":0,"end":1}]}`,
+	}
+	dir := t.TempDir()
+	for name, body := range cases {
+		path := filepath.Join(dir, name+".json")
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		tf, err := LoadTimingFile(path)
+		if err == nil {
+			_, err = tf.Apply(testVerses())
+		}
+		if err == nil {
+			t.Fatalf("%s: expected validation error", name)
+		}
+		if !strings.Contains(err.Error(), "timings") && name != "field" {
+			t.Fatalf("%s: expected error to point at the entry, got %v", name, err)
+		}
+	}
+}
+
+func TestTimingFileRanges(t *testing.T) {
+	f := TimingFile{Version: timingFileVersion, Timings: []TimingEntry{
+		{Surah: 113, Ayah: 1}, {Surah: 113, Ayah: 2}, {Surah: 113, Ayah: 1}, {Surah: 113, Ayah: 3},
+		{Surah: 112, Ayah: 1}, {Surah: 112, Ayah: 2},
+		{Surah: 2, Ayah: 255}, {Surah: 2, Ayah: 285},
+	}}
+	got := f.Ranges()
+	want := []AyahRange{{113, 1, 3}, {112, 1, 2}, {2, 255, 255}, {2, 285, 285}}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d ranges, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("range %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
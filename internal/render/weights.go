@@ -0,0 +1,207 @@
+package render
+
+import (
+	"math"
+	"strings"
+	"time"
+	"unicode"
+
+	"qgencodex/internal/audio"
+)
+
+const (
+	maddWeight   = 0.5
+	maddahWeight = 2.0
+	shaddaWeight = 0.5
+	pauseWeight  = 2.0
+	minWordShare = 0.5
+)
+
+// wordWeight estimates how long a word takes to recite relative to its
+// neighbours: one unit per letter, plus extra for long vowels, madd signs
+// and shadda. Pause marks add to the word they follow (see wordWeights).
+func wordWeight(word string) float64 {
+	weight := 0.0
+	for _, r := range word {
+		switch {
+		case r == 'ـ':
+			continue
+		case r == 'ٓ': // maddah above: madd lazim/muttasil
+			weight += maddahWeight
+		case r == 'ٰ': // dagger alef
+			weight += 1 + maddWeight
+		case r == 'ۥ' || r == 'ۦ': // small waw/yeh (silah)
+			weight += maddWeight
+		case r == 'ّ': // shadda
+			weight += shaddaWeight
+		case isWaqfMark(r):
+			continue
+		case unicode.IsLetter(r):
+			weight++
+			if r == 'ا' || r == 'ى' || r == 'آ' {
+				weight += maddWeight
+			}
+		}
+	}
+	return weight
+}
+
+// isWaqfMark reports whether r is a Quranic pause sign.
+func isWaqfMark(r rune) bool {
+	return r >= 'ۖ' && r <= 'ۜ'
+}
+
+func hasWaqfMark(word string) bool {
+	return strings.IndexFunc(word, isWaqfMark) >= 0
+}
+
+// wordWeights returns per-word weights; a pause mark (attached or standalone)
+// lengthens the word it follows.
+func wordWeights(words []string) []float64 {
+	weights := make([]float64, len(words))
+	for i, w := range words {
+		weights[i] = wordWeight(w)
+		if hasWaqfMark(w) {
+			target := i
+			if weights[i] == 0 && i > 0 {
+				target = i - 1
+			}
+			weights[target] += pauseWeight
+		}
+	}
+	for i, w := range weights {
+		if w == 0 {
+			// Standalone pause marks and non-letter tokens (digits, Latin
+			// punctuation) still get a short slot, so no word is zero-length.
+			weights[i] = minWordShare
+		}
+	}
+	return weights
+}
+
+// SplitWordTimings distributes [start, end] across words in proportion to
+// their estimated recitation length.
+func SplitWordTimings(words []string, start, end time.Duration) []WordTiming {
+	if len(words) == 0 || end <= start {
+		return nil
+	}
+	return splitByWeights(words, wordWeights(words), start, end)
+}
+
+// SplitWordTimingsAroundPauses is SplitWordTimings for audio with known
+// pauses: each pause inside [start, end] is placed on the word boundary that
+// best matches its position, and words are only spread over speech time.
+func SplitWordTimingsAroundPauses(words []string, start, end time.Duration, silences []audio.Silence) []WordTiming {
+	if len(words) == 0 || end <= start {
+		return nil
+	}
+	spans := speechSpans(start, end, silences)
+	if len(spans) <= 1 {
+		if len(spans) == 1 {
+			return splitByWeights(words, wordWeights(words), spans[0].start, spans[0].end)
+		}
+		return SplitWordTimings(words, start, end)
+	}
+	weights := wordWeights(words)
+	totalWeight := 0.0
+	for _, w := range weights {
+		totalWeight += w
+	}
+	totalSpeech := time.Duration(0)
+	for _, s := range spans {
+		totalSpeech += s.end - s.start
+	}
+	if totalWeight <= 0 || totalSpeech <= 0 {
+		return SplitWordTimings(words, start, end)
+	}
+	// boundaries[k] is the number of words recited before the end of span k.
+	boundaries := make([]int, len(spans))
+	elapsed := time.Duration(0)
+	prev := 0
+	for k, s := range spans {
+		elapsed += s.end - s.start
+		if k == len(spans)-1 {
+			boundaries[k] = len(words)
+			break
+		}
+		target := float64(elapsed) / float64(totalSpeech)
+		best := prev
+		bestDiff := math.Inf(1)
+		cum := 0.0
+		for i := 0; i <= len(words); i++ {
+			if i > 0 {
+				cum += weights[i-1] / totalWeight
+			}
+			if i < prev {
+				continue
+			}
+			if diff := math.Abs(cum - target); diff < bestDiff {
+				best = i
+				bestDiff = diff
+			}
+		}
+		boundaries[k] = best
+		prev = best
+	}
+	out := make([]WordTiming, 0, len(words))
+	from := 0
+	for k, s := range spans {
+		to := boundaries[k]
+		if to > from {
+			out = append(out, splitByWeights(words[from:to], weights[from:to], s.start, s.end)...)
+		}
+		from = to
+	}
+	return out
+}
+
+type timeSpan struct {
+	start time.Duration
+	end   time.Duration
+}
+
+// speechSpans returns the parts of [start, end] not covered by silences.
+func speechSpans(start, end time.Duration, silences []audio.Silence) []timeSpan {
+	spans := []timeSpan{}
+	cursor := start
+	for _, s := range silences {
+		if s.End <= cursor || s.Start >= end {
+			continue
+		}
+		if s.Start > cursor {
+			spans = append(spans, timeSpan{start: cursor, end: s.Start})
+		}
+		if s.End > cursor {
+			cursor = s.End
+		}
+	}
+	if cursor < end {
+		spans = append(spans, timeSpan{start: cursor, end: end})
+	}
+	return spans
+}
+
+func splitByWeights(words []string, weights []float64, start, end time.Duration) []WordTiming {
+	total := 0.0
+	for _, w := range weights {
+		total += w
+	}
+	out := make([]WordTiming, len(words))
+	duration := end - start
+	cursor := start
+	cum := 0.0
+	for i, w := range words {
+		cum += weights[i]
+		e := end
+		if i < len(words)-1 {
+			if total > 0 {
+				e = start + time.Duration(float64(duration)*cum/total)
+			} else {
+				e = start + duration*time.Duration(i+1)/time.Duration(len(words))
+			}
+		}
+		out[i] = WordTiming{Word: w, Start: cursor, End: e, Source: SourceEstimated}
+		cursor = e
+	}
+	return out
+}
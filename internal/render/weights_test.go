@@ -0,0 +1,62 @@
+package render
+
+import (
+	"testing"
+	"time"
+
+	"qgencodex/internal/audio"
+)
+
+func TestSplitWordTimingsWeightsLongWords(t *testing.T) {
+	// "مِنَ" is a short particle; "ٱلضَّآلِّينَ" has shadda and a maddah.
+	words := []string{"مِنَ", "ٱلضَّآلِّينَ"}
+	timings := SplitWordTimings(words, 0, 4*time.Second)
+	if len(timings) != 2 {
+		t.Fatalf("expected 2 word timings, got %d", len(timings))
+	}
+	short := timings[0].End - timings[0].Start
+	long := timings[1].End - timings[1].Start
+	if long <= 2*short {
+		t.Fatalf("expected long word to get much more time, got %v vs %v", short, long)
+	}
+	if timings[1].End != 4*time.Second {
+		t.Fatalf("expected last word to end at ayah end, got %v", timings[1].End)
+	}
+}
+
+func TestSplitWordTimingsPauseMarkLengthensPreviousWord(t *testing.T) {
+	words := []string{"لَا", "رَيْبَ", "ۛ", "فِيهِ"}
+	timings := SplitWordTimings(words, 0, 4*time.Second)
+	if len(timings) != 4 {
+		t.Fatalf("expected 4 word timings, got %d", len(timings))
+	}
+	mark := timings[2].End - timings[2].Start
+	rayb := timings[1].End - timings[1].Start
+	fihi := timings[3].End - timings[3].Start
+	if mark <= 0 || mark >= fihi {
+		t.Fatalf("expected standalone pause mark to take a short slot, got %v", timings[2])
+	}
+	if rayb <= fihi {
+		t.Fatalf("expected word before pause mark to be longer, got %v vs %v", rayb, fihi)
+	}
+}
+
+func TestSplitWordTimingsAroundPauses(t *testing.T) {
+	words := []string{"ab", "cd", "ef", "gh"}
+	silences := []audio.Silence{{Start: 2 * time.Second, End: 3 * time.Second}}
+	timings := SplitWordTimingsAroundPauses(words, 0, 5*time.Second, silences)
+	if len(timings) != 4 {
+		t.Fatalf("expected 4 word timings, got %d", len(timings))
+	}
+	if timings[1].End != 2*time.Second || timings[2].Start != 3*time.Second {
+		t.Fatalf("expected pause on the middle word boundary, got %v and %v", timings[1].End, timings[2].Start)
+	}
+	for _, wt := range timings {
+		if wt.Start < 3*time.Second && wt.End > 2*time.Second {
+			t.Fatalf("word %q overlaps the pause: %v-%v", wt.Word, wt.Start, wt.End)
+		}
+	}
+	if timings[3].End != 5*time.Second {
+		t.Fatalf("expected last word to end at ayah end")
+	}
+}
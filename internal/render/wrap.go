@@ -13,7 +13,9 @@ const (
 	avgSpaceWidth = 0.33
 )
 
-func wrapText(text string, maxWidth int, fontSize int) []string {
+// wrapText breaks text into lines no wider than maxWidth, measured with
+// fontFile (see textWidth).
+func wrapText(text string, maxWidth int, fontSize int, fontFile string) []string {
 	clean := sanitizeText(text)
 	if clean == "" || maxWidth <= 0 || fontSize <= 0 {
 		return []string{clean}
@@ -30,9 +32,9 @@ func wrapText(text string, maxWidth int, fontSize int) []string {
 		if current != "" {
 			candidate = current + " " + word
 		}
-		if approxWidth(candidate, fontSize) > threshold {
+		if textWidth(fontFile, candidate, fontSize) > threshold {
 			if current == "" {
-				parts := splitLongWord(word, threshold, fontSize)
+				parts := splitLongWord(word, threshold, fontSize, fontFile)
 				lines = append(lines, parts...)
 				current = ""
 				continue
@@ -56,7 +58,7 @@ func maybeElongateLines(cfg config.VideoConfig, lines []string, maxWidth int, fo
 	out := make([]string, 0, len(lines))
 	target := float64(maxWidth) * 0.90
 	for _, line := range lines {
-		out = append(out, elongateLine(line, target, fontSize, cfg.ElongateCount))
+		out = append(out, elongateLine(line, target, fontSize, cfg.ElongateCount, cfg.Font.File))
 	}
 	return out
 }
@@ -68,14 +70,14 @@ func elongateText(text string, count int) string {
 	return text
 }
 
-func elongateLine(line string, target float64, fontSize int, count int) string {
+func elongateLine(line string, target float64, fontSize int, count int, fontFile string) string {
 	if line == "" || target <= 0 || fontSize <= 0 {
 		return line
 	}
 	if strings.Contains(line, "_") {
 		return elongateText(line, count)
 	}
-	if approxWidth(line, fontSize) >= target {
+	if textWidth(fontFile, line, fontSize) >= target {
 		return line
 	}
 	runes := []rune(line)
@@ -85,7 +87,7 @@ func elongateLine(line string, target float64, fontSize int, count int) string {
 	}
 	maxInsert := 32
 	posIdx := 0
-	for i := 0; i < maxInsert && approxWidth(string(runes), fontSize) < target; i++ {
+	for i := 0; i < maxInsert && textWidth(fontFile, string(runes), fontSize) < target; i++ {
 		insertAt := positions[posIdx]
 		runes = insertRunes(runes, insertAt, 'ـ', normalizedElongateCount(count))
 		for j := range positions {
@@ -168,7 +170,7 @@ func isKashidaEligible(r rune) bool {
 	}
 }
 
-func splitLongWord(word string, threshold float64, fontSize int) []string {
+func splitLongWord(word string, threshold float64, fontSize int, fontFile string) []string {
 	var lines []string
 	var current strings.Builder
 	for _, r := range word {
@@ -177,7 +179,7 @@ func splitLongWord(word string, threshold float64, fontSize int) []string {
 			continue
 		}
 		next := current.String() + string(r)
-		if approxWidth(next, fontSize) > threshold && current.Len() > 0 {
+		if textWidth(fontFile, next, fontSize) > threshold && current.Len() > 0 {
 			lines = append(lines, current.String())
 			current.Reset()
 		}
@@ -189,6 +191,7 @@ func splitLongWord(word string, threshold float64, fontSize int) []string {
 	return lines
 }
 
+// approxWidth estimates the width of text from per-rune averages.
 func approxWidth(text string, fontSize int) float64 {
 	var chars int
 	var spaces int
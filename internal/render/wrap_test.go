@@ -10,7 +10,7 @@ import (
 
 func TestWrapTextSplitsLongLine(t *testing.T) {
 	text := "one two three four five"
-	lines := wrapText(text, 80, 20)
+	lines := wrapText(text, 80, 20, "")
 	if len(lines) < 2 {
 		t.Fatalf("expected wrapped lines, got %v", lines)
 	}
@@ -18,7 +18,7 @@ func TestWrapTextSplitsLongLine(t *testing.T) {
 
 func TestWrapTextDoesNotStartWithCombining(t *testing.T) {
 	text := "بِسْمِ اللَّهِ"
-	lines := wrapText(text, 30, 30)
+	lines := wrapText(text, 30, 30, "")
 	for _, line := range lines {
 		for i, r := range line {
 			if i == 0 && unicode.Is(unicode.Mn, r) {
@@ -2,10 +2,29 @@ package retry
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
-// Do executes fn up to attempts times with exponential backoff.
+// permanentError stops Do from retrying.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so Do returns it at once instead of retrying.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err: err}
+}
+
+// Do executes fn up to attempts times with exponential backoff, stopping
+// early on an error wrapped with Permanent.
 func Do(ctx context.Context, attempts int, baseDelay time.Duration, fn func() error) error {
 	if attempts <= 0 {
 		attempts = 1
@@ -23,6 +42,10 @@ func Do(ctx context.Context, attempts int, baseDelay time.Duration, fn func() er
 		if err == nil {
 			return nil
 		}
+		var permanent permanentError
+		if errors.As(err, &permanent) {
+			return permanent.err
+		}
 		if i == attempts-1 {
 			break
 		}
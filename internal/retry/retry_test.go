@@ -35,3 +35,18 @@ func TestDoContextCancel(t *testing.T) {
 		t.Fatalf("expected context canceled, got %v", err)
 	}
 }
+
+func TestDoStopsOnPermanent(t *testing.T) {
+	attempts := 0
+	cause := errors.New("bad request")
+	err := Do(context.Background(), 3, 1*time.Millisecond, func() error {
+		attempts++
+		return Permanent(cause)
+	})
+	if attempts != 1 {
+		t.Fatalf("expected 1 attempt, got %d", attempts)
+	}
+	if err != cause {
+		t.Fatalf("expected the unwrapped error, got %v", err)
+	}
+}
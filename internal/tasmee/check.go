@@ -0,0 +1,361 @@
+// Package tasmee checks a recitation from memory against the mushaf text.
+package tasmee
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"qgencodex/internal/align"
+	"qgencodex/internal/quran"
+	"qgencodex/internal/recognize"
+)
+
+// Kind is how a reference word was recited, or the kind of a mistake.
+type Kind string
+
+const (
+	Correct     Kind = "correct"
+	Skipped     Kind = "skipped"
+	Inserted    Kind = "inserted"
+	Substituted Kind = "substituted"
+	Repeated    Kind = "repeated"
+	OutOfOrder  Kind = "out-of-order"
+)
+
+const (
+	// matchSimilarity is the letter similarity at which a recited word counts
+	// as the expected one, so small transcription slips are not reported.
+	matchSimilarity = 0.8
+	// minMovedWords is the shortest skipped run looked for elsewhere in the
+	// recitation before it is reported as skipped.
+	minMovedWords = 2
+)
+
+// Word is one reference word and how it was recited. Skipped words carry the
+// gap between their recited neighbours.
+type Word struct {
+	Surah int
+	Ayah  int
+	// Index is the 1-based position of the word in its ayah.
+	Index   int
+	Text    string
+	Recited string
+	Start   time.Duration
+	End     time.Duration
+	Kind    Kind
+}
+
+// Issue is one mistake. Surah, Ayah and Word locate the first reference word
+// involved; insertions point at the reference word they follow.
+type Issue struct {
+	Kind     Kind
+	Surah    int
+	Ayah     int
+	Word     int
+	Expected string
+	Recited  string
+	Start    time.Duration
+	End      time.Duration
+}
+
+// Report is the word-level comparison of a recitation with its reference.
+type Report struct {
+	Words  []Word
+	Issues []Issue
+}
+
+// CorrectWords counts the reference words recited in place without mistakes.
+func (r Report) CorrectWords() int {
+	n := 0
+	for _, w := range r.Words {
+		if w.Kind == Correct {
+			n++
+		}
+	}
+	return n
+}
+
+// Accuracy is the share of reference words recited correctly.
+func (r Report) Accuracy() float64 {
+	if len(r.Words) == 0 {
+		return 0
+	}
+	return float64(r.CorrectWords()) / float64(len(r.Words))
+}
+
+type opKind int
+
+const (
+	opMatch opKind = iota
+	opSubstitute
+	opSkip
+	opInsert
+)
+
+// op is one step of the word diff; ref or rec is -1 when the step has none.
+type op struct {
+	kind opKind
+	ref  int
+	rec  int
+}
+
+type recited struct {
+	align.WordTiming
+	norm string
+}
+
+// Check diffs timed recited words against the reference verses.
+func Check(verses []quran.Verse, words []align.WordTiming) Report {
+	var (
+		report Report
+		ref    []string
+	)
+	for _, v := range verses {
+		index := 0
+		for _, text := range strings.Fields(v.Text) {
+			norm := recognize.NormalizeWord(text)
+			if norm == "" {
+				continue
+			}
+			index++
+			ref = append(ref, norm)
+			report.Words = append(report.Words, Word{Surah: v.SurahMeta.Number, Ayah: v.NumberInSurah, Index: index, Text: text})
+		}
+	}
+	var rec []recited
+	for _, w := range words {
+		if norm := recognize.NormalizeWord(w.Word); norm != "" {
+			rec = append(rec, recited{WordTiming: w, norm: norm})
+		}
+	}
+	recNorm := make([]string, len(rec))
+	for i, w := range rec {
+		recNorm[i] = w.norm
+	}
+	c := checker{report: &report, ref: ref, rec: rec, ops: diff(ref, recNorm)}
+	c.run()
+	return report
+}
+
+type checker struct {
+	report *Report
+	ref    []string
+	rec    []recited
+	ops    []op
+}
+
+func (c *checker) run() {
+	for _, o := range c.ops {
+		switch o.kind {
+		case opMatch:
+			c.place(&c.report.Words[o.ref], o.rec, Correct)
+		case opSubstitute:
+			c.place(&c.report.Words[o.ref], o.rec, Substituted)
+		}
+	}
+	skips, inserts := c.runs(opSkip), c.runs(opInsert)
+	moved := make(map[int]bool)
+	for _, skip := range skips {
+		if len(skip) < minMovedWords {
+			continue
+		}
+		for k, insert := range inserts {
+			if moved[k] || !c.sameText(c.refSpan(skip), c.recSpan(insert)) {
+				continue
+			}
+			moved[k] = true
+			c.markMoved(skip, insert)
+			break
+		}
+	}
+	for k, insert := range inserts {
+		if moved[k] {
+			continue
+		}
+		c.markInserted(insert)
+	}
+	for _, o := range c.ops {
+		if o.kind == opSubstitute {
+			w := c.report.Words[o.ref]
+			c.addIssue(Substituted, w, w.Text, w.Recited, w.Start, w.End)
+		}
+	}
+	for _, skip := range skips {
+		if c.report.Words[c.ops[skip[0]].ref].Kind == OutOfOrder {
+			continue
+		}
+		c.markSkipped(skip)
+	}
+	// Order by recording time, keeping reference order for ties.
+	issues := c.report.Issues
+	sort.SliceStable(issues, func(a, b int) bool { return issues[a].Start < issues[b].Start })
+}
+
+func (c *checker) place(w *Word, rec int, kind Kind) {
+	w.Recited = c.rec[rec].Word
+	w.Start = c.rec[rec].Start
+	w.End = c.rec[rec].End
+	w.Kind = kind
+}
+
+// runs groups consecutive ops of one kind as lists of op positions.
+func (c *checker) runs(kind opKind) [][]int {
+	var (
+		out     [][]int
+		current []int
+	)
+	for i, o := range c.ops {
+		if o.kind == kind {
+			current = append(current, i)
+			continue
+		}
+		if len(current) > 0 {
+			out = append(out, current)
+			current = nil
+		}
+	}
+	if len(current) > 0 {
+		out = append(out, current)
+	}
+	return out
+}
+
+func (c *checker) refSpan(run []int) []string {
+	out := make([]string, len(run))
+	for i, pos := range run {
+		out[i] = c.ref[c.ops[pos].ref]
+	}
+	return out
+}
+
+func (c *checker) recSpan(run []int) []string {
+	out := make([]string, len(run))
+	for i, pos := range run {
+		out[i] = c.rec[c.ops[pos].rec].norm
+	}
+	return out
+}
+
+func (c *checker) sameText(a, b []string) bool {
+	longest := max(len(a), len(b))
+	return longest > 0 && float64(commonWords(a, b)) >= matchSimilarity*float64(longest)
+}
+
+// markMoved records reference words that were skipped in place but recited
+// elsewhere, such as ayahs recited in the wrong order.
+func (c *checker) markMoved(skip, insert []int) {
+	for i, pos := range skip {
+		w := &c.report.Words[c.ops[pos].ref]
+		if i < len(insert) {
+			c.place(w, c.ops[insert[i]].rec, OutOfOrder)
+		} else {
+			w.Kind = OutOfOrder
+		}
+	}
+	first := c.report.Words[c.ops[skip[0]].ref]
+	start, end := c.recTimes(insert)
+	c.addIssue(OutOfOrder, first, c.refText(skip), c.recText(insert), start, end)
+}
+
+// markInserted reports extra recited words, as repeated when they say again
+// the reference words next to them.
+func (c *checker) markInserted(insert []int) {
+	before, after := -1, len(c.ref)
+	for i := insert[0] - 1; i >= 0; i-- {
+		if c.ops[i].ref >= 0 {
+			before = c.ops[i].ref
+			break
+		}
+	}
+	for i := insert[len(insert)-1] + 1; i < len(c.ops); i++ {
+		if c.ops[i].ref >= 0 {
+			after = c.ops[i].ref
+			break
+		}
+	}
+	words := c.recSpan(insert)
+	start, end := c.recTimes(insert)
+	n := len(words)
+	for _, from := range []int{before - n + 1, after} {
+		if from < 0 || from+n > len(c.ref) || !sameWords(c.ref[from:from+n], words) {
+			continue
+		}
+		parts := make([]string, n)
+		for i := range parts {
+			parts[i] = c.report.Words[from+i].Text
+		}
+		c.addIssue(Repeated, c.report.Words[from], strings.Join(parts, " "), c.recText(insert), start, end)
+		return
+	}
+	at := before
+	if at < 0 {
+		at = 0
+	}
+	if at >= len(c.report.Words) {
+		c.report.Issues = append(c.report.Issues, Issue{Kind: Inserted, Recited: c.recText(insert), Start: start, End: end})
+		return
+	}
+	c.addIssue(Inserted, c.report.Words[at], "", c.recText(insert), start, end)
+}
+
+// markSkipped reports reference words that were not recited, timed at the
+// gap between the recited words around them.
+func (c *checker) markSkipped(skip []int) {
+	var start, end time.Duration
+	for i := skip[0] - 1; i >= 0; i-- {
+		if r := c.ops[i].rec; r >= 0 {
+			start = c.rec[r].End
+			break
+		}
+	}
+	end = start
+	for i := skip[len(skip)-1] + 1; i < len(c.ops); i++ {
+		if r := c.ops[i].rec; r >= 0 {
+			end = c.rec[r].Start
+			break
+		}
+	}
+	if end < start {
+		end = start
+	}
+	for _, pos := range skip {
+		w := &c.report.Words[c.ops[pos].ref]
+		w.Kind = Skipped
+		w.Start, w.End = start, end
+	}
+	c.addIssue(Skipped, c.report.Words[c.ops[skip[0]].ref], c.refText(skip), "", start, end)
+}
+
+func (c *checker) addIssue(kind Kind, at Word, expected, recited string, start, end time.Duration) {
+	c.report.Issues = append(c.report.Issues, Issue{
+		Kind:     kind,
+		Surah:    at.Surah,
+		Ayah:     at.Ayah,
+		Word:     at.Index,
+		Expected: expected,
+		Recited:  recited,
+		Start:    start,
+		End:      end,
+	})
+}
+
+func (c *checker) refText(run []int) string {
+	parts := make([]string, len(run))
+	for i, pos := range run {
+		parts[i] = c.report.Words[c.ops[pos].ref].Text
+	}
+	return strings.Join(parts, " ")
+}
+
+func (c *checker) recText(run []int) string {
+	parts := make([]string, len(run))
+	for i, pos := range run {
+		parts[i] = c.rec[c.ops[pos].rec].Word
+	}
+	return strings.Join(parts, " ")
+}
+
+func (c *checker) recTimes(run []int) (time.Duration, time.Duration) {
+	return c.rec[c.ops[run[0]].rec].Start, c.rec[c.ops[run[len(run)-1]].rec].End
+}
@@ -0,0 +1,73 @@
+package tasmee
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"qgencodex/internal/align"
+	"qgencodex/internal/quran"
+)
+
+func surahIkhlas() []quran.Verse {
+	texts := []string{"قُلْ هُوَ اللَّهُ أَحَدٌ", "اللَّهُ الصَّمَدُ", "لَمْ يَلِدْ وَلَمْ يُولَدْ", "وَلَمْ يَكُن لَّهُ كُفُوًا أَحَدٌ"}
+	verses := make([]quran.Verse, len(texts))
+	for i, text := range texts {
+		verses[i] = quran.Verse{NumberInSurah: i + 1, Text: text, SurahMeta: quran.SurahMeta{Number: 112}}
+	}
+	return verses
+}
+
+func recitedWords(text string) []align.WordTiming {
+	var words []align.WordTiming
+	start := time.Duration(0)
+	for _, w := range strings.Fields(text) {
+		words = append(words, align.WordTiming{Word: w, Start: start, End: start + 400*time.Millisecond})
+		start += 500 * time.Millisecond
+	}
+	return words
+}
+
+func TestCheckCleanRecitation(t *testing.T) {
+	report := Check(surahIkhlas(), recitedWords("قل هو الله احد الله الصمد لم يلد ولم يولد ولم يكن له كفوا احد"))
+	if len(report.Issues) != 0 || report.Accuracy() != 1 {
+		t.Fatalf("expected no issues, got %+v", report.Issues)
+	}
+	if w := report.Words[4]; w.Ayah != 2 || w.Index != 1 || w.Start != 2*time.Second {
+		t.Fatalf("unexpected word timing: %+v", w)
+	}
+}
+
+func TestCheckReportsMistakes(t *testing.T) {
+	report := Check(surahIkhlas(), recitedWords("قل هو الله واحد الله الصمد لم يلد ولم يلد ولم يولد ولم يكن كفوا احد"))
+	want := []Issue{
+		{Kind: Substituted, Surah: 112, Ayah: 1, Word: 4, Expected: "أَحَدٌ", Recited: "واحد", Start: 1500 * time.Millisecond, End: 1900 * time.Millisecond},
+		{Kind: Repeated, Surah: 112, Ayah: 3, Word: 2, Expected: "يَلِدْ وَلَمْ", Recited: "يلد ولم", Start: 3500 * time.Millisecond, End: 4400 * time.Millisecond},
+		{Kind: Skipped, Surah: 112, Ayah: 4, Word: 3, Expected: "لَّهُ", Start: 6900 * time.Millisecond, End: 7000 * time.Millisecond},
+	}
+	if len(report.Issues) != len(want) {
+		t.Fatalf("expected %d issues, got %+v", len(want), report.Issues)
+	}
+	for i := range want {
+		if report.Issues[i] != want[i] {
+			t.Fatalf("issue %d: expected %+v, got %+v", i, want[i], report.Issues[i])
+		}
+	}
+	if report.CorrectWords() != len(report.Words)-2 {
+		t.Fatalf("unexpected correct count %d of %d", report.CorrectWords(), len(report.Words))
+	}
+}
+
+func TestCheckReportsAyahsOutOfOrder(t *testing.T) {
+	report := Check(surahIkhlas(), recitedWords("قل هو الله احد لم يلد ولم يولد الله الصمد ولم يكن له كفوا احد"))
+	if len(report.Issues) != 1 {
+		t.Fatalf("expected one issue, got %+v", report.Issues)
+	}
+	issue := report.Issues[0]
+	if issue.Kind != OutOfOrder || issue.Ayah != 2 || issue.Start != 4*time.Second || issue.Recited != "الله الصمد" {
+		t.Fatalf("unexpected issue: %+v", issue)
+	}
+	if w := report.Words[5]; w.Kind != OutOfOrder || w.Start != 4500*time.Millisecond {
+		t.Fatalf("unexpected moved word: %+v", w)
+	}
+}
@@ -0,0 +1,154 @@
+package tasmee
+
+const (
+	// Substituting costs more than a skip or an insertion, so a recited ayah
+	// swapped with the next one diffs as a skip plus an insertion.
+	substituteCost = 3
+	indelCost      = 2
+)
+
+// diffBand is how far, in words, the edit path may stray from the diagonal
+// beyond the length difference of the two texts; about the longest ayah, so a
+// skipped or swapped ayah still diffs as one.
+const diffBand = 128
+
+// diff is a word-level edit script from ref to rec. Similar words match for
+// free. Only cells within diffBand of the diagonal are computed, and each
+// cell keeps the step that reached it so the traceback compares no words.
+func diff(ref, rec []string) []op {
+	n, m := len(ref), len(rec)
+	band := diffBand + max(n-m, m-n)
+	// Row i holds columns lo(i)..hi(i) around the scaled diagonal.
+	lo := func(i int) int {
+		if n == 0 {
+			return 0
+		}
+		return max(0, i*m/n-band)
+	}
+	hi := func(i int) int {
+		if n == 0 {
+			return m
+		}
+		return min(m, i*m/n+band)
+	}
+	offsets := make([]int, n+2)
+	for i := 0; i <= n; i++ {
+		offsets[i+1] = offsets[i] + hi(i) - lo(i) + 1
+	}
+	cost := make([]int32, offsets[n+1])
+	steps := make([]opKind, offsets[n+1])
+	at := func(i, j int) (int32, bool) {
+		if i < 0 || j < lo(i) || j > hi(i) {
+			return 0, false
+		}
+		return cost[offsets[i]+j-lo(i)], true
+	}
+	for i := 0; i <= n; i++ {
+		for j := lo(i); j <= hi(i); j++ {
+			best, step := int32(-1), opMatch
+			if i > 0 && j > 0 {
+				if v, ok := at(i-1, j-1); ok {
+					best, step = v, opMatch
+					if !similar(ref[i-1], rec[j-1]) {
+						best, step = v+substituteCost, opSubstitute
+					}
+				}
+			}
+			if v, ok := at(i-1, j); ok && (best < 0 || v+indelCost < best) {
+				best, step = v+indelCost, opSkip
+			}
+			if v, ok := at(i, j-1); ok && (best < 0 || v+indelCost < best) {
+				best, step = v+indelCost, opInsert
+			}
+			if best < 0 {
+				best = 0
+			}
+			cost[offsets[i]+j-lo(i)], steps[offsets[i]+j-lo(i)] = best, step
+		}
+	}
+	var ops []op
+	i, j := n, m
+	for i > 0 || j > 0 {
+		switch kind := steps[offsets[i]+j-lo(i)]; kind {
+		case opMatch, opSubstitute:
+			i, j = i-1, j-1
+			ops = append(ops, op{kind: kind, ref: i, rec: j})
+		case opSkip:
+			i--
+			ops = append(ops, op{kind: opSkip, ref: i, rec: -1})
+		default:
+			j--
+			ops = append(ops, op{kind: opInsert, ref: -1, rec: j})
+		}
+	}
+	for a, b := 0, len(ops)-1; a < b; a, b = a+1, b-1 {
+		ops[a], ops[b] = ops[b], ops[a]
+	}
+	return ops
+}
+
+// similar reports whether two normalized words differ by at most a small
+// share of their letters.
+func similar(a, b string) bool {
+	if a == b {
+		return true
+	}
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return true
+	}
+	return 1-float64(editDistance(ra, rb))/float64(longest) >= matchSimilarity
+}
+
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			sub := prev[j-1]
+			if a[i-1] != b[j-1] {
+				sub++
+			}
+			curr[j] = min(sub, prev[j]+1, curr[j-1]+1)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// commonWords is the length of the longest common subsequence of similar words.
+func commonWords(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			switch {
+			case similar(a[i-1], b[j-1]):
+				curr[j] = prev[j-1] + 1
+			case prev[j] >= curr[j-1]:
+				curr[j] = prev[j]
+			default:
+				curr[j] = curr[j-1]
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func sameWords(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !similar(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,28 @@
+package tasmee
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestDiffLongPassage(t *testing.T) {
+	// A surah-length recitation: one word skipped, one added.
+	rng := rand.New(rand.NewSource(1))
+	ref := make([]string, 6000)
+	for i := range ref {
+		word := make([]byte, 6)
+		for k := range word {
+			word[k] = byte('a' + rng.Intn(26))
+		}
+		ref[i] = string(word)
+	}
+	rec := append(append([]string{}, ref[:1000]...), ref[1001:3000]...)
+	rec = append(append(rec, "zzzzzzzz"), ref[3000:]...)
+	counts := map[opKind]int{}
+	for _, o := range diff(ref, rec) {
+		counts[o.kind]++
+	}
+	if counts[opSkip] != 1 || counts[opInsert] != 1 || counts[opSubstitute] != 0 || counts[opMatch] != len(ref)-1 {
+		t.Fatalf("expected one skip and one insertion, got %v", counts)
+	}
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"io"
 	"log"
 	"os"
 	"strings"
@@ -38,6 +39,11 @@ func NewLogger(level string) *Logger {
 	}
 }
 
+// SetOutput sends log lines to w instead of stdout.
+func (l *Logger) SetOutput(w io.Writer) {
+	l.base.SetOutput(w)
+}
+
 func (l *Logger) Debugf(format string, args ...any) {
 	if l.level <= LevelDebug {
 		l.base.Printf("DEBUG: "+format, args...)